@@ -0,0 +1,121 @@
+package admin
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Conflux-Chain/go-conflux-util/api"
+	"github.com/Conflux-Chain/go-conflux-util/api/middleware"
+	"github.com/Conflux-Chain/go-conflux-util/blockchain/sync"
+	"github.com/gin-gonic/gin"
+)
+
+// Controller is implemented by types to inspect and steer a running sync pipeline, e.g. sync.Syncer[T].
+type Controller interface {
+	Status() sync.Status
+	ReorgWindow() []sync.CheckpointBlock
+	Pause(ctx context.Context) error
+	Resume()
+	Rewind(ctx context.Context, blockNumber uint64) error
+	Reindex(ctx context.Context, from, to uint64) error
+}
+
+// Block is a recently processed block in reorg window.
+type Block struct {
+	Number uint64 `json:"number"`
+	Hash   string `json:"hash"`
+}
+
+type rewindRequest struct {
+	BlockNumber *uint64 `json:"blockNumber" binding:"required"`
+}
+
+type reindexRequest struct {
+	From *uint64 `json:"from" binding:"required"`
+	To   *uint64 `json:"to" binding:"required"`
+}
+
+// NewRouteFactory returns a factory to register admin routes under the given path, which could be used
+// in api.MustServe.
+func NewRouteFactory(path string, controller Controller) api.RouteFactory {
+	return func(router *gin.Engine) {
+		Register(router.Group(path), controller)
+	}
+}
+
+// Register registers admin routes into the given router group as below:
+//
+//   - GET /status: sync phase, cursor and health.
+//   - GET /reorg: recently processed blocks in reorg window.
+//   - POST /pause: pause the sync pipeline.
+//   - POST /resume: resume the sync pipeline.
+//   - POST /rewind: revert blocks since the given block number, e.g. {"blockNumber": 100}.
+//   - POST /reindex: re-index blocks in the given range, e.g. {"from": 100, "to": 200}.
+func Register(group *gin.RouterGroup, controller Controller) {
+	group.GET("/status", middleware.Wrap(func(c *gin.Context) (any, error) {
+		return controller.Status(), nil
+	}))
+
+	group.GET("/reorg", middleware.Wrap(func(c *gin.Context) (any, error) {
+		blocks := controller.ReorgWindow()
+
+		result := make([]Block, 0, len(blocks))
+		for _, v := range blocks {
+			result = append(result, Block{v.Number, v.Hash})
+		}
+
+		return result, nil
+	}))
+
+	group.POST("/pause", middleware.Wrap(func(c *gin.Context) (any, error) {
+		if err := controller.Pause(c.Request.Context()); err != nil {
+			return nil, err
+		}
+
+		return controller.Status(), nil
+	}))
+
+	group.POST("/resume", middleware.Wrap(func(c *gin.Context) (any, error) {
+		controller.Resume()
+		return controller.Status(), nil
+	}))
+
+	group.POST("/rewind", middleware.Wrap(func(c *gin.Context) (any, error) {
+		var req rewindRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			return nil, api.ErrValidation(err)
+		}
+
+		if err := controller.Rewind(c.Request.Context(), *req.BlockNumber); err != nil {
+			return nil, controlError(err)
+		}
+
+		return controller.Status(), nil
+	}))
+
+	group.POST("/reindex", middleware.Wrap(func(c *gin.Context) (any, error) {
+		var req reindexRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			return nil, api.ErrValidation(err)
+		}
+
+		if *req.From > *req.To {
+			return nil, api.ErrValidationStrf("from (%v) > to (%v)", *req.From, *req.To)
+		}
+
+		if err := controller.Reindex(c.Request.Context(), *req.From, *req.To); err != nil {
+			return nil, controlError(err)
+		}
+
+		return controller.Status(), nil
+	}))
+}
+
+// controlError converts the error of invalid block number from controller into a validation error.
+func controlError(err error) error {
+	if errors.Is(err, sync.ErrBlockNotProcessed) || errors.Is(err, sync.ErrBlockNotFinalized) {
+		return api.ErrValidation(err)
+	}
+
+	return err
+}
@@ -0,0 +1,94 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/Conflux-Chain/go-conflux-util/api/testutil"
+	"github.com/Conflux-Chain/go-conflux-util/blockchain/sync"
+	polltestutil "github.com/Conflux-Chain/go-conflux-util/blockchain/sync/poll/testutil"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+var _ Controller = (*sync.Syncer[polltestutil.Data])(nil)
+
+type testController struct {
+	status  sync.Status
+	rewind  uint64
+	reindex [2]uint64
+}
+
+func (c *testController) Status() sync.Status { return c.status }
+
+func (c *testController) ReorgWindow() []sync.CheckpointBlock {
+	return []sync.CheckpointBlock{{Number: 1, Hash: "0x1"}, {Number: 2, Hash: "0x2"}}
+}
+
+func (c *testController) Pause(ctx context.Context) error {
+	c.status.Paused = true
+	return nil
+}
+
+func (c *testController) Resume() { c.status.Paused = false }
+
+func (c *testController) Rewind(ctx context.Context, blockNumber uint64) error {
+	if blockNumber > c.status.NextBlockNumber {
+		return fmt.Errorf("%w, next = %v", sync.ErrBlockNotProcessed, c.status.NextBlockNumber)
+	}
+
+	c.rewind = blockNumber
+	return nil
+}
+
+func (c *testController) Reindex(ctx context.Context, from, to uint64) error {
+	if to >= c.status.NextBlockNumber {
+		return fmt.Errorf("%w, finalized = %v", sync.ErrBlockNotFinalized, c.status.NextBlockNumber-1)
+	}
+
+	c.reindex = [2]uint64{from, to}
+	return nil
+}
+
+func newTestEngine(controller Controller) *testutil.Engine {
+	engine := gin.New()
+	NewRouteFactory("/admin/sync", controller)(engine)
+	return testutil.NewEngine(engine, "/admin/sync")
+}
+
+func TestAdmin(t *testing.T) {
+	controller := testController{status: sync.Status{Phase: "latest", NextBlockNumber: 10, Healthy: true}}
+	engine := newTestEngine(&controller)
+
+	engine.Get("/status").AssertSuccess(t, map[string]any{
+		"phase":           "latest",
+		"paused":          false,
+		"nextBlockNumber": float64(10),
+		"processedAt":     "0001-01-01T00:00:00Z",
+		"healthy":         true,
+	})
+
+	engine.Get("/reorg").AssertSuccess(t, []any{
+		map[string]any{"number": float64(1), "hash": "0x1"},
+		map[string]any{"number": float64(2), "hash": "0x2"},
+	})
+
+	engine.Post("/pause").MustExec()
+	assert.True(t, controller.status.Paused)
+
+	engine.Post("/resume").MustExec()
+	assert.False(t, controller.status.Paused)
+
+	engine.Post("/rewind").MustWithJSONBody(map[string]any{}).AssertErrValidation(t)
+	engine.Post("/rewind").MustWithJSONBody(map[string]any{"blockNumber": 0}).MustExec()
+	assert.Equal(t, uint64(0), controller.rewind)
+	engine.Post("/rewind").MustWithJSONBody(map[string]any{"blockNumber": 5}).MustExec()
+	assert.Equal(t, uint64(5), controller.rewind)
+	engine.Post("/rewind").MustWithJSONBody(map[string]any{"blockNumber": 11}).AssertErrValidation(t)
+
+	engine.Post("/reindex").MustWithJSONBody(map[string]any{"from": 3, "to": 2}).AssertErrValidation(t)
+	engine.Post("/reindex").MustWithJSONBody(map[string]any{"from": 2, "to": 3}).MustExec()
+	assert.Equal(t, [2]uint64{2, 3}, controller.reindex)
+	engine.Post("/reindex").MustWithJSONBody(map[string]any{"from": 2, "to": 10}).AssertErrValidation(t)
+}
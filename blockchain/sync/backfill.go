@@ -0,0 +1,216 @@
+package sync
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/Conflux-Chain/go-conflux-util/blockchain/sync/poll"
+	"github.com/Conflux-Chain/go-conflux-util/blockchain/sync/process"
+	"github.com/Conflux-Chain/go-conflux-util/blockchain/sync/process/db"
+	"github.com/Conflux-Chain/go-conflux-util/channel"
+	"github.com/Conflux-Chain/go-conflux-util/log"
+	"github.com/mcuadros/go-defaults"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type BackfillOption struct {
+	Poller    poll.CatchUpOption
+	Processor db.BatchOption
+}
+
+type BackfillParams[T any] struct {
+	Adapter poll.Adapter[T]
+	DB      *gorm.DB
+
+	// Name is used to persist the backfill progress in checkpoint table, so that the backfill job could be
+	// resumed after service restarted. Note, it must be different from the checkpoint name of live sync.
+	Name string
+
+	// block range [From, To] to backfill, and only the finalized blocks will be backfilled
+	From uint64
+	To   uint64
+}
+
+// Backfill re-processes the finalized blocks in range [From, To] with the given batch processors in parallel,
+// and persists the progress in a separate checkpoint, so that it could run alongside the live sync without
+// changing the main cursor.
+//
+// Note, all processors must implement the db.RangeRevertableProcessor interface, so that the existing data of
+// blocks in batch will be deleted in the same database transaction before re-processed.
+//
+// It returns the next block number to backfill, which is To+1 once completed. If terminated before completed,
+// e.g. context done, the backfill job will be resumed from the persisted progress at next time.
+func Backfill[T channel.Sizable](
+	ctx context.Context, params BackfillParams[T], processors []db.BatchProcessor[T], option ...BackfillOption,
+) (uint64, error) {
+	var opt BackfillOption
+	if len(option) > 0 {
+		opt = option[0]
+	}
+
+	defaults.SetDefaults(&opt)
+
+	if params.From > params.To {
+		return 0, errors.Errorf("Invalid block range, from = %v, to = %v", params.From, params.To)
+	}
+
+	revertables := make([]db.RangeRevertableProcessor, 0, len(processors))
+	for _, v := range processors {
+		revertable, ok := v.(db.RangeRevertableProcessor)
+		if !ok {
+			return 0, errors.Errorf("Processor %T does not support to revert data in range", v)
+		}
+
+		revertables = append(revertables, revertable)
+	}
+
+	// avoid overriding metrics of live sync
+	if opt.Poller.Parallel.Metrics == (poll.MetricsOption{}) {
+		opt.Poller.Parallel.Metrics.Namespace = "backfill/" + params.Name
+	}
+
+	if opt.Processor.Metrics == (poll.MetricsOption{}) {
+		opt.Processor.Metrics.Namespace = "backfill/" + params.Name
+	}
+
+	dbCheckpoint, err := NewDBCheckpoint(params.DB, params.Name)
+	if err != nil {
+		return 0, errors.WithMessage(err, "Failed to create backfill checkpoint")
+	}
+
+	checkpoint, err := NewCheckpointProcessor(dbCheckpoint, params.Adapter, params.From)
+	if err != nil {
+		return 0, errors.WithMessage(err, "Failed to create backfill checkpoint processor")
+	}
+
+	nextBlockNumber := checkpoint.NextBlockNumber()
+	if nextBlockNumber < params.From || nextBlockNumber > params.To+1 {
+		return 0, errors.Errorf("Backfill progress out of range, next = %v, from = %v, to = %v",
+			nextBlockNumber, params.From, params.To)
+	}
+
+	logger := log.WithModule(ModuleName).WithFields(logrus.Fields{
+		"name": params.Name,
+		"from": params.From,
+		"to":   params.To,
+	})
+
+	if nextBlockNumber > params.To {
+		logger.Debug("Backfill already completed")
+		return nextBlockNumber, nil
+	}
+
+	logger.WithField("next", nextBlockNumber).Info("Begin to backfill")
+
+	// delete the existing data at first, and do not modify the given processors
+	batchProcessors := make([]db.BatchProcessor[T], 0, len(processors)+2)
+	batchProcessors = append(batchProcessors, newRangeRevertProcessor[T](nextBlockNumber, revertables))
+	batchProcessors = append(batchProcessors, processors...)
+	batchProcessors = append(batchProcessors, checkpoint)
+
+	processor := db.NewBatchAggregateProcessor(opt.Processor, params.DB, batchProcessors...)
+
+	var wg sync.WaitGroup
+
+	poller := poll.NewBoundedCatchUpPoller(params.Adapter, nextBlockNumber, params.To, opt.Poller)
+	wg.Add(1)
+	go poller.Poll(ctx, &wg)
+
+	wg.Add(1)
+	go process.ProcessCatchUp(ctx, &wg, poller.DataCh(), processor)
+
+	wg.Wait()
+
+	nextBlockNumber = checkpoint.NextBlockNumber()
+
+	if nextBlockNumber > params.To {
+		logger.Info("Backfill completed")
+	} else {
+		logger.WithField("next", nextBlockNumber).Info("Backfill terminated")
+	}
+
+	return nextBlockNumber, nil
+}
+
+var (
+	_ db.BatchProcessor[any]     = (*rangeRevertProcessor[any])(nil)
+	_ db.PipelineBatchProcessor  = (*rangeRevertProcessor[any])(nil)
+	_ db.SavepointBatchProcessor = (*rangeRevertProcessor[any])(nil)
+)
+
+// rangeRevertProcessor deletes the existing data of blocks in batch for all processors, which is used along with
+// other batch processors in the same database transaction, so as to re-process blocks like Syncer.Reindex.
+type rangeRevertProcessor[T any] struct {
+	processors      []db.RangeRevertableProcessor // in reversed order
+	nextBlockNumber uint64
+	batchFirst      uint64 // the first block number in batch
+	batchBlocks     uint64 // number of blocks in batch
+}
+
+func newRangeRevertProcessor[T any](nextBlockNumber uint64, processors []db.RangeRevertableProcessor) *rangeRevertProcessor[T] {
+	// revert in reversed order
+	processors = slices.Clone(processors)
+	slices.Reverse(processors)
+
+	return &rangeRevertProcessor[T]{
+		processors:      processors,
+		nextBlockNumber: nextBlockNumber,
+	}
+}
+
+// BatchProcess implements the db.BatchProcessor[T] interface.
+func (processor *rangeRevertProcessor[T]) BatchProcess(data T) int {
+	if processor.batchBlocks == 0 {
+		processor.batchFirst = processor.nextBlockNumber
+	}
+
+	processor.nextBlockNumber++
+	processor.batchBlocks++
+
+	// not counted in batch size
+	return 0
+}
+
+// BatchExec implements the db.BatchProcessor[T] interface.
+func (processor *rangeRevertProcessor[T]) BatchExec(tx *gorm.DB, createBatchSize int) error {
+	return processor.operation().Exec(tx)
+}
+
+// BatchReset implements the db.BatchProcessor[T] interface.
+func (processor *rangeRevertProcessor[T]) BatchReset() {
+	processor.batchBlocks = 0
+}
+
+// BatchDetach implements the db.PipelineBatchProcessor interface.
+func (processor *rangeRevertProcessor[T]) BatchDetach(createBatchSize int) db.Operation {
+	op := processor.operation()
+	processor.BatchReset()
+
+	return op
+}
+
+// BatchSavepoint implements the db.SavepointBatchProcessor interface.
+func (processor *rangeRevertProcessor[T]) BatchSavepoint() func() {
+	nextBlockNumber := processor.nextBlockNumber
+
+	return func() {
+		processor.nextBlockNumber = nextBlockNumber
+		processor.batchBlocks = 0
+	}
+}
+
+func (processor *rangeRevertProcessor[T]) operation() db.Operation {
+	if processor.batchBlocks == 0 {
+		return db.ComposeOperation()
+	}
+
+	ops := make([]db.Operation, 0, len(processor.processors))
+	for _, v := range processor.processors {
+		ops = append(ops, v.RevertRange(processor.batchFirst, processor.batchFirst+processor.batchBlocks-1))
+	}
+
+	return db.ComposeOperation(ops...)
+}
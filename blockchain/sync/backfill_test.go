@@ -0,0 +1,98 @@
+package sync
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/Conflux-Chain/go-conflux-util/blockchain/sync/poll/testutil"
+	"github.com/Conflux-Chain/go-conflux-util/blockchain/sync/process/db"
+	"github.com/Conflux-Chain/go-conflux-util/store"
+	"github.com/stretchr/testify/assert"
+)
+
+type backfillTestProcessor struct {
+	*testDBProcessor
+	ranges [][2]uint64 // reverted block ranges
+}
+
+func newBackfillTestProcessor() *backfillTestProcessor {
+	return &backfillTestProcessor{testDBProcessor: newTestDBProcessor()}
+}
+
+func (p *backfillTestProcessor) RevertRange(fromBlockNumber, toBlockNumber uint64) db.Operation {
+	p.ranges = append(p.ranges, [2]uint64{fromBlockNumber, toBlockNumber})
+	return dummyDBOp{}
+}
+
+func TestBackfill(t *testing.T) {
+	adapter := &syncerTestAdapter{}
+	adapter.finalized.Store(10)
+
+	storeConfig := store.NewMemoryConfig()
+	storeConfig.Sqlite.Path = filepath.Join(t.TempDir(), "backfill.db")
+	DB := storeConfig.MustOpenOrCreate()
+
+	params := BackfillParams[testutil.Data]{
+		Adapter: adapter,
+		DB:      DB,
+		Name:    "backfill",
+		From:    3,
+		To:      7,
+	}
+
+	// resume from the persisted progress
+	checkpoint, err := NewDBCheckpoint(DB, params.Name)
+	assert.NoError(t, err)
+	assert.NoError(t, checkpoint.Push(4, "DataHash-4").Exec(DB))
+
+	// processor not supports to revert data in range
+	_, err = Backfill(context.Background(), params, []db.BatchProcessor[testutil.Data]{newTestDBProcessor()})
+	assert.Error(t, err)
+
+	// existing data deleted before re-processed
+	processor := newBackfillTestProcessor()
+	next, err := Backfill(context.Background(), params, []db.BatchProcessor[testutil.Data]{processor})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(8), next)
+	processor.assertData(t, [][]uint64{{5, 6, 7}}, nil, nil)
+	assert.Equal(t, [][2]uint64{{5, 7}}, processor.ranges)
+
+	// already completed
+	processor = newBackfillTestProcessor()
+	next, err = Backfill(context.Background(), params, []db.BatchProcessor[testutil.Data]{processor})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(8), next)
+	processor.assertData(t, nil, nil, nil)
+	assert.Nil(t, processor.ranges)
+
+	// progress out of range
+	params.To = 5
+	_, err = Backfill(context.Background(), params, []db.BatchProcessor[testutil.Data]{processor})
+	assert.Error(t, err)
+}
+
+func TestBackfillProcessorsNotModified(t *testing.T) {
+	adapter := &syncerTestAdapter{}
+	adapter.finalized.Store(10)
+
+	storeConfig := store.NewMemoryConfig()
+	storeConfig.Sqlite.Path = filepath.Join(t.TempDir(), "backfill.db")
+	DB := storeConfig.MustOpenOrCreate()
+
+	// spare capacity in the given processors
+	processor := newBackfillTestProcessor()
+	processors := make([]db.BatchProcessor[testutil.Data], 1, 2)
+	processors[0] = processor
+
+	_, err := Backfill(context.Background(), BackfillParams[testutil.Data]{
+		Adapter: adapter,
+		DB:      DB,
+		Name:    "backfill",
+		From:    3,
+		To:      5,
+	}, processors)
+	assert.NoError(t, err)
+
+	assert.Nil(t, processors[:2][1])
+}
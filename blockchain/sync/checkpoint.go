@@ -0,0 +1,437 @@
+package sync
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Conflux-Chain/go-conflux-util/blockchain/sync/poll"
+	"github.com/Conflux-Chain/go-conflux-util/blockchain/sync/process/db"
+	"github.com/Conflux-Chain/go-conflux-util/log"
+	"github.com/mcuadros/go-defaults"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// CheckpointBlock is the database model to persist the recently processed blocks of a sync task.
+type CheckpointBlock struct {
+	ID     uint64
+	Name   string `gorm:"size:64;not null;uniqueIndex:uidx_name_number,priority:1"`
+	Number uint64 `gorm:"not null;uniqueIndex:uidx_name_number,priority:2"`
+	Hash   string `gorm:"size:128;not null"`
+}
+
+func (CheckpointBlock) TableName() string {
+	return "sync_checkpoints"
+}
+
+// Checkpoint is implemented by types that persist the sync progress, so that the sync task could be
+// resumed after service restarted.
+type Checkpoint interface {
+	// Load returns the recently processed blocks in ascending order, or empty if nothing processed yet.
+	Load() ([]CheckpointBlock, error)
+
+	// Push returns a database operation to persist the processed block.
+	Push(blockNumber uint64, blockHash string) db.Operation
+
+	// Pop returns a database operation to remove the processed blocks since the given block number (inclusive).
+	Pop(blockNumber uint64) db.Operation
+
+	// Prune returns a database operation to remove the processed blocks before the given block number (exclusive).
+	Prune(blockNumber uint64) db.Operation
+}
+
+var _ Checkpoint = (*DBCheckpoint)(nil)
+
+// DBCheckpoint implements the Checkpoint interface to persist the sync progress in database.
+//
+// Note, multiple sync tasks could share the same database table with different names.
+type DBCheckpoint struct {
+	db   *gorm.DB
+	name string
+}
+
+// NewDBCheckpoint creates a checkpoint of the given sync task name, and creates the database table if absent.
+func NewDBCheckpoint(db *gorm.DB, name string) (*DBCheckpoint, error) {
+	if len(name) == 0 {
+		return nil, errors.New("Checkpoint name not specified")
+	}
+
+	if err := db.AutoMigrate(&CheckpointBlock{}); err != nil {
+		return nil, errors.WithMessage(err, "Failed to create checkpoint table")
+	}
+
+	return &DBCheckpoint{db, name}, nil
+}
+
+// Load implements the Checkpoint interface.
+func (checkpoint *DBCheckpoint) Load() ([]CheckpointBlock, error) {
+	var blocks []CheckpointBlock
+
+	if err := checkpoint.db.Where("name = ?", checkpoint.name).Order("number ASC").Find(&blocks).Error; err != nil {
+		return nil, err
+	}
+
+	return blocks, nil
+}
+
+// Push implements the Checkpoint interface.
+func (checkpoint *DBCheckpoint) Push(blockNumber uint64, blockHash string) db.Operation {
+	return db.CreateOperation(&CheckpointBlock{
+		Name:   checkpoint.name,
+		Number: blockNumber,
+		Hash:   blockHash,
+	})
+}
+
+// Pop implements the Checkpoint interface.
+func (checkpoint *DBCheckpoint) Pop(blockNumber uint64) db.Operation {
+	return db.DeleteOperation(&CheckpointBlock{}, "name = ? AND number >= ?", checkpoint.name, blockNumber)
+}
+
+// Prune implements the Checkpoint interface.
+func (checkpoint *DBCheckpoint) Prune(blockNumber uint64) db.Operation {
+	return db.DeleteOperation(&CheckpointBlock{}, "name = ? AND number < ?", checkpoint.name, blockNumber)
+}
+
+type CheckpointOption struct {
+	// KeepBlocks is the number of recent blocks to persist for chain reorg detection when sync the latest data.
+	//
+	// Note, it should be greater than the number of blocks between the latest block and the finalized block.
+	KeepBlocks int `default:"1000"`
+}
+
+var (
+	_ db.RevertableProcessor[any] = (*CheckpointProcessor[any])(nil)
+	_ db.RewindableProcessor      = (*CheckpointProcessor[any])(nil)
+	_ db.BatchProcessor[any]      = (*CheckpointProcessor[any])(nil)
+	_ db.PipelineBatchProcessor   = (*CheckpointProcessor[any])(nil)
+	_ db.SavepointBatchProcessor  = (*CheckpointProcessor[any])(nil)
+)
+
+// CheckpointProcessor updates the checkpoint in the same database transaction of other processors.
+//
+// Besides, it maintains the recently processed blocks in memory, so that the sync task could switch
+// between catch up, finalized and latest phases without loading from database again.
+//
+// Note, it should be used in the same goroutine of other database processors, and only the read-only methods
+// are thread-safe, e.g. NextBlockNumber and LastBlock.
+type CheckpointProcessor[T any] struct {
+	option          CheckpointOption
+	checkpoint      Checkpoint // optional, nil indicates in memory only
+	adapter         poll.Adapter[T]
+	mu              sync.RWMutex
+	nextBlockNumber uint64
+	blocks          []CheckpointBlock // recently processed blocks in sequence
+	batch           int               // number of processed blocks in batch
+	processedAt     time.Time         // time of the last processed block
+}
+
+// NewCheckpointProcessor creates a new processor and loads the recently processed blocks from the given checkpoint.
+//
+// If nothing persisted in checkpoint, the sync task will start from the given nextBlockNumber.
+func NewCheckpointProcessor[T any](
+	checkpoint Checkpoint, adapter poll.Adapter[T], nextBlockNumber uint64, option ...CheckpointOption,
+) (*CheckpointProcessor[T], error) {
+	opt := CheckpointOption{}
+	if len(option) > 0 {
+		opt = option[0]
+	}
+
+	defaults.SetDefaults(&opt)
+
+	processor := CheckpointProcessor[T]{
+		option:          opt,
+		checkpoint:      checkpoint,
+		adapter:         adapter,
+		nextBlockNumber: nextBlockNumber,
+	}
+
+	if checkpoint == nil {
+		return &processor, nil
+	}
+
+	blocks, err := checkpoint.Load()
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to load checkpoint")
+	}
+
+	// only the continuous blocks are required
+	for i := len(blocks) - 1; i >= 0; i-- {
+		if i == 0 || blocks[i-1].Number+1 != blocks[i].Number {
+			processor.blocks = blocks[i:]
+			break
+		}
+	}
+
+	if numBlocks := len(processor.blocks); numBlocks > 0 {
+		processor.nextBlockNumber = processor.blocks[numBlocks-1].Number + 1
+	}
+
+	return &processor, nil
+}
+
+// NextBlockNumber returns the next block number to sync.
+func (processor *CheckpointProcessor[T]) NextBlockNumber() uint64 {
+	processor.mu.RLock()
+	defer processor.mu.RUnlock()
+
+	return processor.nextBlockNumber
+}
+
+// ProcessedAt returns the time of the last processed block, or zero time if nothing processed since created.
+func (processor *CheckpointProcessor[T]) ProcessedAt() time.Time {
+	processor.mu.RLock()
+	defer processor.mu.RUnlock()
+
+	return processor.processedAt
+}
+
+// Blocks returns a copy of the recently processed blocks in ascending order.
+func (processor *CheckpointProcessor[T]) Blocks() []CheckpointBlock {
+	processor.mu.RLock()
+	defer processor.mu.RUnlock()
+
+	return slices.Clone(processor.blocks)
+}
+
+// ReorgWindowParams returns the recently processed blocks to initialize the reorg window.
+func (processor *CheckpointProcessor[T]) ReorgWindowParams() poll.ReorgWindowParams {
+	processor.mu.RLock()
+	defer processor.mu.RUnlock()
+
+	if len(processor.blocks) == 0 {
+		return poll.ReorgWindowParams{}
+	}
+
+	// the earliest block should have been finalized
+	params := poll.ReorgWindowParams{
+		FinalizedBlockNumber: processor.blocks[0].Number,
+		FinalizedBlockHash:   processor.blocks[0].Hash,
+		LatestBlocks:         make(map[uint64]string),
+	}
+
+	for _, v := range processor.blocks[1:] {
+		params.LatestBlocks[v.Number] = v.Hash
+	}
+
+	return params
+}
+
+// LastBlock returns the last processed block if any.
+func (processor *CheckpointProcessor[T]) LastBlock() (CheckpointBlock, bool) {
+	processor.mu.RLock()
+	defer processor.mu.RUnlock()
+
+	if len(processor.blocks) == 0 {
+		return CheckpointBlock{}, false
+	}
+
+	return processor.blocks[len(processor.blocks)-1], true
+}
+
+// push pushes the given data in memory, and returns the pushed block number.
+func (processor *CheckpointProcessor[T]) push(data T) uint64 {
+	processor.mu.Lock()
+	defer processor.mu.Unlock()
+
+	blockNumber := processor.nextBlockNumber
+
+	processor.blocks = append(processor.blocks, CheckpointBlock{
+		Number: blockNumber,
+		Hash:   processor.adapter.GetBlockHash(data),
+	})
+
+	if overflow := len(processor.blocks) - processor.option.KeepBlocks; overflow > 0 {
+		processor.blocks = processor.blocks[overflow:]
+	}
+
+	processor.nextBlockNumber++
+	processor.processedAt = time.Now()
+
+	return blockNumber
+}
+
+// Process implements the db.Processor[T] interface.
+func (processor *CheckpointProcessor[T]) Process(data T) db.Operation {
+	blockNumber := processor.push(data)
+
+	if processor.checkpoint == nil {
+		return db.ComposeOperation()
+	}
+
+	return db.ComposeOperation(
+		processor.checkpoint.Push(blockNumber, processor.adapter.GetBlockHash(data)),
+		processor.checkpoint.Prune(processor.blocks[0].Number),
+	)
+}
+
+// Revert implements the db.RevertableProcessor[T] interface.
+func (processor *CheckpointProcessor[T]) Revert(data T) db.Operation {
+	parentBlockHash := processor.adapter.GetParentBlockHash(data)
+
+	// find the common ancestor
+	ancestor := len(processor.blocks) - 1
+	for ancestor >= 0 && processor.blocks[ancestor].Hash != parentBlockHash {
+		ancestor--
+	}
+
+	// should never happen
+	if ancestor < 0 {
+		log.WithModule(ModuleName).WithFields(logrus.Fields{
+			"next":   processor.nextBlockNumber,
+			"parent": parentBlockHash,
+			"blocks": len(processor.blocks),
+		}).Fatal("Failed to find the reverted block in checkpoint")
+	}
+
+	return processor.revertTo(ancestor)
+}
+
+// Rewind implements the db.RewindableProcessor interface.
+func (processor *CheckpointProcessor[T]) Rewind(rewind poll.Rewind) db.Operation {
+	// find the fork block
+	ancestor := len(processor.blocks) - 1
+	for ancestor >= 0 && processor.blocks[ancestor].Number > rewind.ForkBlockNumber {
+		ancestor--
+	}
+
+	// should never happen
+	if ancestor < 0 || processor.blocks[ancestor].Hash != rewind.ForkBlockHash {
+		log.WithModule(ModuleName).WithFields(logrus.Fields{
+			"next":   processor.nextBlockNumber,
+			"fork":   rewind.ForkBlockNumber,
+			"hash":   rewind.ForkBlockHash,
+			"blocks": len(processor.blocks),
+		}).Fatal("Failed to find the fork block in checkpoint")
+	}
+
+	return processor.revertTo(ancestor)
+}
+
+// revertTo removes all blocks after the given ancestor index.
+func (processor *CheckpointProcessor[T]) revertTo(ancestor int) db.Operation {
+	processor.mu.Lock()
+	defer processor.mu.Unlock()
+
+	processor.blocks = processor.blocks[:ancestor+1]
+	processor.nextBlockNumber = processor.blocks[ancestor].Number + 1
+
+	if processor.checkpoint == nil {
+		return db.ComposeOperation()
+	}
+
+	return processor.checkpoint.Pop(processor.nextBlockNumber)
+}
+
+// rewindTo returns a database operation to remove the processed blocks since the given block number (inclusive),
+// along with a function to update the blocks in memory, which should be called once the operation executed.
+//
+// Note, parentBlockHash is the hash of block blockNumber-1, which will be persisted if not in memory, so that
+// the sync task could be resumed from the given block number after service restarted.
+func (processor *CheckpointProcessor[T]) rewindTo(blockNumber uint64, parentBlockHash string) (db.Operation, func()) {
+	var blocks []CheckpointBlock
+	for _, v := range processor.Blocks() {
+		if v.Number < blockNumber {
+			blocks = append(blocks, v)
+		}
+	}
+
+	var ops []db.Operation
+
+	if blockNumber == 0 || len(blocks) > 0 {
+		if processor.checkpoint != nil {
+			ops = append(ops, processor.checkpoint.Pop(blockNumber))
+		}
+	} else {
+		blocks = append(blocks, CheckpointBlock{Number: blockNumber - 1, Hash: parentBlockHash})
+
+		if processor.checkpoint != nil {
+			ops = append(ops,
+				processor.checkpoint.Pop(blockNumber-1),
+				processor.checkpoint.Push(blockNumber-1, parentBlockHash),
+			)
+		}
+	}
+
+	return db.ComposeOperation(ops...), func() {
+		processor.mu.Lock()
+		defer processor.mu.Unlock()
+
+		processor.blocks = blocks
+		processor.nextBlockNumber = blockNumber
+	}
+}
+
+// BatchProcess implements the db.BatchProcessor[T] interface.
+func (processor *CheckpointProcessor[T]) BatchProcess(data T) int {
+	processor.push(data)
+	processor.batch++
+
+	// checkpoint is not counted in batch size
+	return 0
+}
+
+// BatchExec implements the db.BatchProcessor[T] interface.
+//
+// Note, only the last block is persisted in catch up phase, since all blocks have been finalized.
+func (processor *CheckpointProcessor[T]) BatchExec(tx *gorm.DB, createBatchSize int) error {
+	if processor.checkpoint == nil || processor.batch == 0 {
+		return nil
+	}
+
+	last, _ := processor.LastBlock()
+
+	return db.ComposeOperation(
+		processor.checkpoint.Push(last.Number, last.Hash),
+		processor.checkpoint.Prune(last.Number),
+	).Exec(tx)
+}
+
+// BatchReset implements the db.BatchProcessor[T] interface.
+func (processor *CheckpointProcessor[T]) BatchReset() {
+	if processor.batch == 0 {
+		return
+	}
+
+	processor.mu.Lock()
+	defer processor.mu.Unlock()
+
+	// keep consistent with database
+	processor.blocks = processor.blocks[len(processor.blocks)-1:]
+	processor.batch = 0
+}
+
+// BatchDetach implements the db.PipelineBatchProcessor interface.
+func (processor *CheckpointProcessor[T]) BatchDetach(createBatchSize int) db.Operation {
+	if processor.checkpoint == nil || processor.batch == 0 {
+		processor.BatchReset()
+		return db.ComposeOperation()
+	}
+
+	last, _ := processor.LastBlock()
+	processor.BatchReset()
+
+	return db.ComposeOperation(
+		processor.checkpoint.Push(last.Number, last.Hash),
+		processor.checkpoint.Prune(last.Number),
+	)
+}
+
+// BatchSavepoint implements the db.SavepointBatchProcessor interface.
+func (processor *CheckpointProcessor[T]) BatchSavepoint() func() {
+	processor.mu.RLock()
+	blocks := slices.Clone(processor.blocks)
+	nextBlockNumber := processor.nextBlockNumber
+	processor.mu.RUnlock()
+
+	return func() {
+		processor.mu.Lock()
+		defer processor.mu.Unlock()
+
+		processor.blocks = blocks
+		processor.nextBlockNumber = nextBlockNumber
+		processor.batch = 0
+	}
+}
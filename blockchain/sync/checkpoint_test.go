@@ -0,0 +1,131 @@
+package sync
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/Conflux-Chain/go-conflux-util/blockchain/sync/poll"
+	"github.com/Conflux-Chain/go-conflux-util/blockchain/sync/poll/testutil"
+	"github.com/Conflux-Chain/go-conflux-util/blockchain/sync/process/db"
+	"github.com/Conflux-Chain/go-conflux-util/store"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func newTestFileDB(t *testing.T) *gorm.DB {
+	storeConfig := store.NewMemoryConfig()
+	storeConfig.Sqlite.Path = filepath.Join(t.TempDir(), "test.db")
+
+	return storeConfig.MustOpenOrCreate()
+}
+
+func assertCheckpoint(t *testing.T, checkpoint Checkpoint, expected map[uint64]string) {
+	blocks, err := checkpoint.Load()
+	assert.NoError(t, err)
+
+	actual := make(map[uint64]string)
+	for _, v := range blocks {
+		actual[v.Number] = v.Hash
+	}
+
+	assert.Equal(t, expected, actual)
+}
+
+func TestCheckpointDB(t *testing.T) {
+	DB := newTestFileDB(t)
+
+	checkpoint, err := NewDBCheckpoint(DB, "test")
+	assert.NoError(t, err)
+
+	// catch up from block 2 to 5, and only the last block persisted
+	cp, err := NewCheckpointProcessor(checkpoint, testutil.MustNewAdapter([]uint64{5}, nil), 2)
+	assert.NoError(t, err)
+
+	nextBlockNumber := CatchUpDB(context.Background(), CatchupParamsDB[testutil.Data]{
+		Adapter:    testutil.MustNewAdapter([]uint64{3, 5}, nil),
+		Processor:  db.BatchOption{BatchSize: 3},
+		DB:         DB,
+		Checkpoint: cp,
+	}, newTestDBProcessor())
+
+	assert.Equal(t, uint64(6), nextBlockNumber)
+	assertCheckpoint(t, checkpoint, map[uint64]string{5: "DataHash-5"})
+
+	// restart to sync the latest blocks with chain reorg
+	adapter := testutil.MustNewAdapter([]uint64{5}, []testutil.Data{
+		{Number: 6, Hash: "DataHash-6", ParentHash: "DataHash-5"},
+		{Number: 7, Hash: "DataHash-7", ParentHash: "DataHash-6"},
+
+		// revert block 7
+		{Number: 8, Hash: "DataHash-88", ParentHash: "DataHash-77"},
+		{Number: 7, Hash: "DataHash-77", ParentHash: "DataHash-6"},
+		{Number: 8, Hash: "DataHash-88", ParentHash: "DataHash-77"},
+	})
+
+	cp, err = NewCheckpointProcessor(checkpoint, adapter, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(6), cp.NextBlockNumber())
+
+	processor := newTestDBProcessor()
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+
+	err = StartLatestDB(ctx, &wg, ParamsDB[testutil.Data]{
+		Adapter:    adapter,
+		DB:         DB,
+		Checkpoint: cp,
+	}, processor)
+	assert.NoError(t, err)
+
+	processor.waitFor(t, testutil.Data{Number: 8, Hash: "DataHash-88", ParentHash: "DataHash-77"})
+
+	cancel()
+	wg.Wait()
+
+	processor.assertData(t, nil, []uint64{6, 7, 8}, [][]uint64{{7}})
+	assertCheckpoint(t, checkpoint, map[uint64]string{
+		5: "DataHash-5",
+		6: "DataHash-6",
+		7: "DataHash-77",
+		8: "DataHash-88",
+	})
+
+	// restart again to rebuild the reorg window
+	cp, err = NewCheckpointProcessor(checkpoint, adapter, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(9), cp.NextBlockNumber())
+	assert.Equal(t, poll.ReorgWindowParams{
+		FinalizedBlockNumber: 5,
+		FinalizedBlockHash:   "DataHash-5",
+		LatestBlocks: map[uint64]string{
+			6: "DataHash-6",
+			7: "DataHash-77",
+			8: "DataHash-88",
+		},
+	}, cp.ReorgWindowParams())
+}
+
+func TestCheckpointKeepBlocks(t *testing.T) {
+	DB := newTestFileDB(t)
+
+	checkpoint, err := NewDBCheckpoint(DB, "test")
+	assert.NoError(t, err)
+
+	adapter := testutil.MustNewAdapter([]uint64{10}, nil)
+
+	cp, err := NewCheckpointProcessor(checkpoint, adapter, 1, CheckpointOption{KeepBlocks: 3})
+	assert.NoError(t, err)
+
+	for i := uint64(1); i <= 5; i++ {
+		data, _ := adapter.GetBlockData(context.Background(), i)
+		assert.NoError(t, cp.Process(data).Exec(DB))
+	}
+
+	assertCheckpoint(t, checkpoint, map[uint64]string{
+		3: "DataHash-3",
+		4: "DataHash-4",
+		5: "DataHash-5",
+	})
+}
@@ -34,6 +34,8 @@ type AdapterOption struct {
 }
 
 var _ poll.Adapter[EpochData] = (*Adapter)(nil)
+var _ poll.Subscriber = (*Adapter)(nil)
+var _ poll.RangeAdapter[EpochData] = (*Adapter)(nil)
 
 // Adapter implements the poll.Adapter[T] interface to poll data from evm RPC.
 type Adapter struct {
@@ -120,18 +122,20 @@ func (adapter *Adapter) GetLatestBlockNumber(ctx context.Context) (uint64, error
 func (adapter *Adapter) GetBlockData(ctx context.Context, blockNumber uint64) (EpochData, error) {
 	var data EpochData
 
-	if err := data.queryBlocks(adapter.client, blockNumber); err != nil {
+	client := adapter.client.WithContext(ctx)
+
+	if err := data.queryBlocks(client, blockNumber); err != nil {
 		return EpochData{}, errors.WithMessage(err, "Failed to query epoch blocks")
 	}
 
 	if !adapter.option.IgnoreReceipts {
-		if err := data.queryReceipts(adapter.client); err != nil {
+		if err := data.queryReceipts(client); err != nil {
 			return EpochData{}, errors.WithMessage(err, "Failed to query epoch receipts")
 		}
 	}
 
 	if !adapter.option.IgnoreTraces {
-		if err := data.queryTraces(adapter.client); err != nil {
+		if err := data.queryTraces(client); err != nil {
 			return EpochData{}, errors.WithMessage(err, "Failed to query epoch traces")
 		}
 	}
@@ -139,6 +143,27 @@ func (adapter *Adapter) GetBlockData(ctx context.Context, blockNumber uint64) (E
 	return data, nil
 }
 
+// GetBlockDataRange implements the poll.RangeAdapter[T] interface via JSON-RPC batch requests.
+func (adapter *Adapter) GetBlockDataRange(ctx context.Context, from, to uint64) ([]EpochData, error) {
+	if from > to {
+		return nil, errors.Errorf("Invalid epoch range [%v, %v]", from, to)
+	}
+
+	return batchQueryEpochData(ctx, adapter.client, from, to, adapter.option)
+}
+
+// SubscribeNewBlocks implements the poll.Subscriber interface, which requires a websocket URL.
+func (adapter *Adapter) SubscribeNewBlocks(ctx context.Context) (<-chan struct{}, error) {
+	epochCh := make(chan types.WebsocketEpochResponse, 16)
+
+	sub, err := adapter.client.SubscribeEpochs(epochCh)
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to subscribe epochs")
+	}
+
+	return poll.NewNotifyChannel(ctx, sub, epochCh), nil
+}
+
 // GetBlockHash implements the poll.Adapter[T] interface.
 func (adapter *Adapter) GetBlockHash(data EpochData) string {
 	return data.Blocks[len(data.Blocks)-1].Hash.String()
@@ -1,10 +1,14 @@
 package core
 
 import (
+	"context"
+	"encoding/json"
+
 	sdk "github.com/Conflux-Chain/go-conflux-sdk"
 	"github.com/Conflux-Chain/go-conflux-sdk/types"
 	"github.com/DmitriyVTitov/size"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	rpc "github.com/openweb3/go-rpc-provider"
 	"github.com/pkg/errors"
 )
 
@@ -21,6 +25,29 @@ func (data EpochData) Size() int {
 	return size.Of(data)
 }
 
+// BlockNumber implements the db.Numbered interface, which returns the epoch number.
+func (data EpochData) BlockNumber() uint64 {
+	return data.Blocks[len(data.Blocks)-1].EpochNumber.ToInt().Uint64()
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface, e.g. to replay dead letters.
+func (data *EpochData) UnmarshalJSON(input []byte) error {
+	type epochData EpochData
+
+	var decoded epochData
+	if err := json.Unmarshal(input, &decoded); err != nil {
+		return err
+	}
+
+	*data = EpochData(decoded)
+
+	for _, block := range data.Blocks {
+		data.numTxs += len(block.Transactions)
+	}
+
+	return nil
+}
+
 func (data *EpochData) queryBlocks(client sdk.ClientOperator, epochNumber uint64) error {
 	epoch := types.NewEpochNumberUint64(epochNumber)
 
@@ -73,6 +100,12 @@ func (data *EpochData) queryReceipts(client sdk.ClientOperator) error {
 		return errors.WithMessage(err, "Failed to get epoch receipts by pivot block hash")
 	}
 
+	return data.setReceipts(epochReceipts)
+}
+
+func (data *EpochData) setReceipts(epochReceipts [][]types.TransactionReceipt) error {
+	pivotBlock := data.Blocks[len(data.Blocks)-1]
+
 	// detect reorg
 	epochNumber := pivotBlock.EpochNumber.ToInt().Uint64()
 	for _, blockReceipts := range epochReceipts {
@@ -103,6 +136,12 @@ func (data *EpochData) queryTraces(client sdk.ClientOperator) error {
 		return errors.WithMessage(err, "Failed to get epoch traces by epoch number")
 	}
 
+	return data.setTraces(traces)
+}
+
+func (data *EpochData) setTraces(traces types.EpochTrace) error {
+	pivotBlock := data.Blocks[len(data.Blocks)-1]
+
 	// try to detect reorg
 	for i, v := range traces.CfxTraces {
 		if v.EpochHash != pivotBlock.Hash {
@@ -114,3 +153,183 @@ func (data *EpochData) queryTraces(client sdk.ClientOperator) error {
 
 	return nil
 }
+
+// batchQueryEpochData queries the whole blockchain data in epoch range [from, to] via JSON-RPC batch requests.
+func batchQueryEpochData(ctx context.Context, client *sdk.Client, from, to uint64, option AdapterOption) ([]EpochData, error) {
+	client = client.WithContext(ctx)
+
+	result, err := batchQueryEpochBlocks(client, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	if !option.IgnoreReceipts {
+		receipts := make([][][]types.TransactionReceipt, len(result))
+
+		err = batchCallNonEmptyEpochs(client, result, func(i int, pivotBlock *types.Block) rpc.BatchElem {
+			return rpc.BatchElem{
+				Method: "cfx_getEpochReceipts",
+				Args:   []any{types.NewEpochOrBlockHashWithBlockHash(pivotBlock.Hash, true), false},
+				Result: &receipts[i],
+			}
+		})
+		if err != nil {
+			return nil, errors.WithMessage(err, "Failed to batch get epoch receipts")
+		}
+
+		for i := range result {
+			if result[i].numTxs == 0 {
+				err = result[i].queryReceipts(client) // no RPC for empty epoch
+			} else {
+				err = result[i].setReceipts(receipts[i])
+			}
+
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if !option.IgnoreTraces {
+		traces := make([]types.EpochTrace, len(result))
+
+		err = batchCallNonEmptyEpochs(client, result, func(i int, pivotBlock *types.Block) rpc.BatchElem {
+			return rpc.BatchElem{
+				Method: "trace_epoch",
+				Args:   []any{types.NewEpochNumber(pivotBlock.EpochNumber)},
+				Result: &traces[i],
+			}
+		})
+		if err != nil {
+			return nil, errors.WithMessage(err, "Failed to batch get epoch traces")
+		}
+
+		for i := range result {
+			if result[i].numTxs == 0 {
+				err = result[i].queryTraces(client) // no RPC for empty epoch
+			} else {
+				err = result[i].setTraces(traces[i])
+			}
+
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// batchQueryEpochBlocks queries blocks of epochs in range [from, to] via JSON-RPC batch requests.
+func batchQueryEpochBlocks(client *sdk.Client, from, to uint64) ([]EpochData, error) {
+	// query block hashes of epochs
+	epochBlockHashes := make([][]types.Hash, to-from+1)
+	batch := make([]rpc.BatchElem, 0, len(epochBlockHashes))
+
+	for i := range epochBlockHashes {
+		batch = append(batch, rpc.BatchElem{
+			Method: "cfx_getBlocksByEpoch",
+			Args:   []any{types.NewEpochNumberUint64(from + uint64(i))},
+			Result: &epochBlockHashes[i],
+		})
+	}
+
+	if err := client.BatchCallRPC(batch); err != nil {
+		return nil, errors.WithMessage(err, "Failed to batch get block hashes by epoch number")
+	}
+
+	var numBlocks int
+	for i, v := range batch {
+		if v.Error != nil {
+			return nil, errors.WithMessagef(v.Error, "Failed to get block hashes by epoch number %v", from+uint64(i))
+		}
+
+		if len(epochBlockHashes[i]) == 0 {
+			return nil, errors.Errorf("Epoch blocks not found by number %v", from+uint64(i))
+		}
+
+		numBlocks += len(epochBlockHashes[i])
+	}
+
+	// query blocks with pivot assumption
+	blocks := make([]*types.Block, 0, numBlocks)
+	batch = make([]rpc.BatchElem, 0, numBlocks)
+
+	for i, blockHashes := range epochBlockHashes {
+		pivotHash := blockHashes[len(blockHashes)-1]
+
+		for _, v := range blockHashes {
+			blocks = append(blocks, nil)
+			batch = append(batch, rpc.BatchElem{
+				Method: "cfx_getBlockByHashWithPivotAssumption",
+				Args:   []any{v, pivotHash, hexutil.Uint64(from + uint64(i))},
+				Result: &blocks[len(blocks)-1],
+			})
+		}
+	}
+
+	if err := client.BatchCallRPC(batch); err != nil {
+		return nil, errors.WithMessage(err, "Failed to batch get blocks by hash with pivot assumption")
+	}
+
+	for i, v := range batch {
+		if v.Error != nil {
+			return nil, errors.WithMessage(v.Error, "Failed to get block by hash with pivot assumption")
+		}
+
+		if blocks[i] == nil {
+			return nil, errors.Errorf("Block not found by hash %v", v.Args[0])
+		}
+	}
+
+	// group blocks by epoch
+	result := make([]EpochData, 0, len(epochBlockHashes))
+
+	for _, blockHashes := range epochBlockHashes {
+		data := EpochData{Blocks: blocks[:len(blockHashes):len(blockHashes)]}
+		blocks = blocks[len(blockHashes):]
+
+		for _, v := range data.Blocks {
+			data.numTxs += len(v.Transactions)
+		}
+
+		// detect temp chain reorg
+		if len(result) > 0 {
+			prevPivotBlock := result[len(result)-1].Blocks[len(result[len(result)-1].Blocks)-1]
+			if data.Blocks[len(data.Blocks)-1].ParentHash != prevPivotBlock.Hash {
+				return nil, errors.Errorf("Pivot block parent hash mismatch, epoch = %v", from+uint64(len(result)))
+			}
+		}
+
+		result = append(result, data)
+	}
+
+	return result, nil
+}
+
+// batchCallNonEmptyEpochs calls RPC via JSON-RPC batch request, and ignores the epochs without any transaction.
+func batchCallNonEmptyEpochs(client *sdk.Client, data []EpochData, newBatchElem func(i int, pivotBlock *types.Block) rpc.BatchElem) error {
+	var batch []rpc.BatchElem
+
+	for i, v := range data {
+		if v.numTxs > 0 {
+			batch = append(batch, newBatchElem(i, v.Blocks[len(v.Blocks)-1]))
+		}
+	}
+
+	if len(batch) == 0 {
+		return nil
+	}
+
+	if err := client.BatchCallRPC(batch); err != nil {
+		return errors.WithMessage(err, "Failed to batch call RPC")
+	}
+
+	for _, v := range batch {
+		if v.Error != nil {
+			return errors.WithMessagef(v.Error, "Failed to call RPC %v with args %v", v.Method, v.Args)
+		}
+	}
+
+	return nil
+}
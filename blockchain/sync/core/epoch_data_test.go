@@ -0,0 +1,169 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/Conflux-Chain/go-conflux-util/blockchain/sync/poll/testutil"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// testPivotHash returns the pivot block hash of the given epoch.
+func testPivotHash(epochNumber uint64) types.Hash {
+	return types.Hash(common.BigToHash(new(big.Int).SetUint64(epochNumber + 1000)).Hex())
+}
+
+// testEpochBlockHashes returns block hashes of the given epoch, which includes 2 blocks for even epoch number.
+func testEpochBlockHashes(epochNumber uint64) []types.Hash {
+	if epochNumber%2 == 1 {
+		return []types.Hash{testPivotHash(epochNumber)}
+	}
+
+	return []types.Hash{
+		types.Hash(common.BigToHash(new(big.Int).SetUint64(epochNumber + 5000)).Hex()),
+		testPivotHash(epochNumber),
+	}
+}
+
+func parseEpochNumber(param json.RawMessage) (uint64, error) {
+	var epoch hexutil.Uint64
+	if err := json.Unmarshal(param, &epoch); err != nil {
+		return 0, errors.WithMessage(err, "Invalid epoch number")
+	}
+
+	return uint64(epoch), nil
+}
+
+// testEpochRPCHandler returns a RPC handler that serves epoch blocks till the given latest epoch number.
+func testEpochRPCHandler(latest uint64) testutil.RPCHandler {
+	return func(method string, params []json.RawMessage) (any, error) {
+		switch method {
+		case "cfx_getStatus":
+			return map[string]any{"networkId": "0x1", "chainId": "0x1"}, nil
+		case "cfx_getBlocksByEpoch":
+			epochNumber, err := parseEpochNumber(params[0])
+			if err != nil {
+				return nil, err
+			}
+
+			if epochNumber > latest {
+				return nil, errors.Errorf("Epoch %v is greater than the latest epoch", epochNumber)
+			}
+
+			return testEpochBlockHashes(epochNumber), nil
+		case "cfx_getBlockByHashWithPivotAssumption":
+			var hash, pivotHash types.Hash
+			if err := json.Unmarshal(params[0], &hash); err != nil {
+				return nil, err
+			}
+
+			if err := json.Unmarshal(params[1], &pivotHash); err != nil {
+				return nil, err
+			}
+
+			epochNumber, err := parseEpochNumber(params[2])
+			if err != nil {
+				return nil, err
+			}
+
+			if pivotHash != testPivotHash(epochNumber) {
+				return nil, errors.New("Pivot block hash mismatch")
+			}
+
+			return map[string]any{
+				"hash":         hash,
+				"parentHash":   testPivotHash(epochNumber - 1),
+				"epochNumber":  hexutil.Uint64(epochNumber),
+				"transactions": []any{},
+			}, nil
+		default:
+			return nil, errors.Errorf("Unsupported method %v", method)
+		}
+	}
+}
+
+func newTestAdapter(t *testing.T, handler testutil.RPCHandler) (*Adapter, *testutil.RPCServer) {
+	server := testutil.NewRPCServer(handler)
+	t.Cleanup(server.Close)
+
+	adapter, err := NewAdapter(server.URL, AdapterOption{IgnoreReceipts: true, IgnoreTraces: true})
+	assert.NoError(t, err)
+	t.Cleanup(adapter.Close)
+
+	return adapter, server
+}
+
+func TestAdapterGetBlockDataRange(t *testing.T) {
+	adapter, server := newTestAdapter(t, testEpochRPCHandler(10))
+
+	// responses of batch request are in reversed order
+	data, err := adapter.GetBlockDataRange(context.Background(), 3, 6)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, len(data))
+
+	for i, v := range data {
+		epochNumber := uint64(3 + i)
+		assert.Equal(t, epochNumber, v.BlockNumber())
+
+		var blockHashes []types.Hash
+		for _, block := range v.Blocks {
+			blockHashes = append(blockHashes, block.Hash)
+		}
+
+		assert.Equal(t, testEpochBlockHashes(epochNumber), blockHashes)
+		assert.Equal(t, testPivotHash(epochNumber).String(), adapter.GetBlockHash(v))
+	}
+
+	assert.Equal(t, 4, len(server.Requests("cfx_getBlocksByEpoch")))
+	assert.Equal(t, 6, len(server.Requests("cfx_getBlockByHashWithPivotAssumption")))
+
+	// range crosses the latest epoch
+	_, err = adapter.GetBlockDataRange(context.Background(), 8, 12)
+	assert.ErrorContains(t, err, "Failed to get block hashes by epoch number 11")
+
+	// invalid range
+	_, err = adapter.GetBlockDataRange(context.Background(), 5, 4)
+	assert.ErrorContains(t, err, "Invalid epoch range")
+}
+
+func TestAdapterGetBlockDataRangePartialError(t *testing.T) {
+	handler := testEpochRPCHandler(10)
+	adapter, _ := newTestAdapter(t, func(method string, params []json.RawMessage) (any, error) {
+		if method == "cfx_getBlockByHashWithPivotAssumption" {
+			if epochNumber, _ := parseEpochNumber(params[2]); epochNumber == 4 {
+				return nil, errors.New("mock error")
+			}
+		}
+
+		return handler(method, params)
+	})
+
+	_, err := adapter.GetBlockDataRange(context.Background(), 3, 6)
+	assert.ErrorContains(t, err, "Failed to get block by hash with pivot assumption")
+	assert.ErrorContains(t, err, "mock error")
+}
+
+func TestAdapterGetBlockDataRangeReorg(t *testing.T) {
+	handler := testEpochRPCHandler(10)
+	adapter, _ := newTestAdapter(t, func(method string, params []json.RawMessage) (any, error) {
+		result, err := handler(method, params)
+
+		// pivot block of epoch 5 is on another fork
+		if method == "cfx_getBlockByHashWithPivotAssumption" && err == nil {
+			if epochNumber, _ := parseEpochNumber(params[2]); epochNumber == 5 {
+				result.(map[string]any)["parentHash"] = testPivotHash(100)
+			}
+		}
+
+		return result, err
+	})
+
+	_, err := adapter.GetBlockDataRange(context.Background(), 3, 6)
+	assert.ErrorContains(t, err, "Pivot block parent hash mismatch, epoch = 5")
+}
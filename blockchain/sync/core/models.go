@@ -0,0 +1,264 @@
+package core
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Epoch is the database model of core space epoch.
+type Epoch struct {
+	Number    uint64 `gorm:"primaryKey;autoIncrement:false"`
+	PivotHash string `gorm:"size:66;not null;index"`
+	Timestamp uint64 `gorm:"not null"` // timestamp of pivot block
+	NumBlocks int    `gorm:"not null"`
+	NumTxs    int    `gorm:"not null"` // including the skipped transactions
+}
+
+func (Epoch) TableName() string {
+	return "core_epochs"
+}
+
+// Block is the database model of core space block.
+type Block struct {
+	ID            uint64
+	EpochNumber   uint64 `gorm:"not null;index"`
+	Index         int    `gorm:"not null"` // index in epoch, and the pivot block is the last one
+	Hash          string `gorm:"size:66;not null;index"`
+	ParentHash    string `gorm:"size:66;not null"`
+	Height        uint64 `gorm:"not null"`
+	BlockNumber   uint64 `gorm:"not null"`
+	Miner         string `gorm:"size:64;not null"`
+	GasLimit      string `gorm:"size:78;not null"`
+	GasUsed       string `gorm:"size:78"`
+	BaseFeePerGas string `gorm:"size:78"` // empty if not supported
+	Timestamp     uint64 `gorm:"not null"`
+	NumTxs        int    `gorm:"not null"`
+}
+
+func (Block) TableName() string {
+	return "core_blocks"
+}
+
+// Transaction is the database model of core space transaction.
+//
+// Note, the same transaction may be packed in multiple blocks, but only executed once. The status is nil
+// if skipped in this block.
+type Transaction struct {
+	ID           uint64
+	EpochNumber  uint64  `gorm:"not null;index"`
+	BlockHash    string  `gorm:"size:66;not null"`
+	Hash         string  `gorm:"size:66;not null;index"`
+	Index        uint64  `gorm:"not null"` // index in block
+	From         string  `gorm:"size:64;not null;index"`
+	To           *string `gorm:"size:64;index"` // nil for contract creation
+	Value        string  `gorm:"size:78;not null"`
+	Nonce        string  `gorm:"size:78;not null"`
+	Gas          string  `gorm:"size:78;not null"`
+	GasPrice     string  `gorm:"size:78"`
+	StorageLimit string  `gorm:"size:78"`
+	Data         []byte
+	Status       *uint64
+}
+
+func (Transaction) TableName() string {
+	return "core_transactions"
+}
+
+// Receipt is the database model of core space transaction receipt.
+type Receipt struct {
+	ID                    uint64
+	EpochNumber           uint64  `gorm:"not null;index"`
+	BlockHash             string  `gorm:"size:66;not null"`
+	TxHash                string  `gorm:"size:66;not null;index"`
+	Index                 uint64  `gorm:"not null"` // index of executed transaction in block
+	From                  string  `gorm:"size:64;not null"`
+	To                    *string `gorm:"size:64"`
+	GasUsed               string  `gorm:"size:78"`
+	GasFee                string  `gorm:"size:78"`
+	ContractCreated       *string `gorm:"size:64"`
+	OutcomeStatus         uint64  `gorm:"not null"`
+	TxExecErrorMsg        *string
+	StorageCollateralized uint64 `gorm:"not null"`
+}
+
+func (Receipt) TableName() string {
+	return "core_receipts"
+}
+
+// Log is the database model of core space event log.
+type Log struct {
+	ID          uint64
+	EpochNumber uint64 `gorm:"not null;index"`
+	BlockHash   string `gorm:"size:66;not null"`
+	TxHash      string `gorm:"size:66;not null;index"`
+	Index       uint64 `gorm:"not null"` // index in epoch
+	Address     string `gorm:"size:64;not null;index"`
+	Topic0      string `gorm:"size:66;index"`
+	Topic1      string `gorm:"size:66"`
+	Topic2      string `gorm:"size:66"`
+	Topic3      string `gorm:"size:66"`
+	Data        []byte
+}
+
+func (Log) TableName() string {
+	return "core_logs"
+}
+
+// Trace is the database model of core space trace, in which action is persisted in JSON format.
+type Trace struct {
+	ID          uint64
+	EpochNumber uint64 `gorm:"not null;index"`
+	BlockHash   string `gorm:"size:66;not null"`
+	TxHash      string `gorm:"size:66;not null;index"`
+	TxPosition  uint64 `gorm:"not null"`
+	Type        string `gorm:"size:32;not null"`
+	Valid       bool   `gorm:"not null"`
+	Action      []byte
+}
+
+func (Trace) TableName() string {
+	return "core_traces"
+}
+
+// Models returns all the database models of core space data, e.g. to create tables.
+func Models() []any {
+	return []any{&Epoch{}, &Block{}, &Transaction{}, &Receipt{}, &Log{}, &Trace{}}
+}
+
+func newBlock(epochNumber uint64, index int, block *types.Block) Block {
+	return Block{
+		EpochNumber:   epochNumber,
+		Index:         index,
+		Hash:          block.Hash.String(),
+		ParentHash:    block.ParentHash.String(),
+		Height:        bigToUint64(block.Height),
+		BlockNumber:   bigToUint64(block.BlockNumber),
+		Miner:         block.Miner.String(),
+		GasLimit:      bigToString(block.GasLimit),
+		GasUsed:       bigToString(block.GasUsed),
+		BaseFeePerGas: bigToString(block.BaseFeePerGas),
+		Timestamp:     bigToUint64(block.Timestamp),
+		NumTxs:        len(block.Transactions),
+	}
+}
+
+func newTransaction(epochNumber uint64, block *types.Block, index int, tx *types.Transaction) Transaction {
+	result := Transaction{
+		EpochNumber:  epochNumber,
+		BlockHash:    block.Hash.String(),
+		Hash:         tx.Hash.String(),
+		Index:        uint64(index),
+		From:         tx.From.String(),
+		To:           addressToString(tx.To),
+		Value:        bigToString(tx.Value),
+		Nonce:        bigToString(tx.Nonce),
+		Gas:          bigToString(tx.Gas),
+		GasPrice:     bigToString(tx.GasPrice),
+		StorageLimit: bigToString(tx.StorageLimit),
+		Data:         hexToBytes(tx.Data),
+	}
+
+	// only executed in the block that has the same block hash
+	if tx.Status != nil && tx.BlockHash != nil && *tx.BlockHash == block.Hash {
+		status := uint64(*tx.Status)
+		result.Status = &status
+	}
+
+	return result
+}
+
+func newReceipt(epochNumber uint64, receipt *types.TransactionReceipt) Receipt {
+	return Receipt{
+		EpochNumber:           epochNumber,
+		BlockHash:             receipt.BlockHash.String(),
+		TxHash:                receipt.TransactionHash.String(),
+		Index:                 uint64(receipt.Index),
+		From:                  receipt.From.String(),
+		To:                    addressToString(receipt.To),
+		GasUsed:               bigToString(receipt.GasUsed),
+		GasFee:                bigToString(receipt.GasFee),
+		ContractCreated:       addressToString(receipt.ContractCreated),
+		OutcomeStatus:         uint64(receipt.OutcomeStatus),
+		TxExecErrorMsg:        receipt.TxExecErrorMsg,
+		StorageCollateralized: uint64(receipt.StorageCollateralized),
+	}
+}
+
+func newLog(epochNumber uint64, index uint64, receipt *types.TransactionReceipt, log *types.Log) Log {
+	result := Log{
+		EpochNumber: epochNumber,
+		BlockHash:   receipt.BlockHash.String(),
+		TxHash:      receipt.TransactionHash.String(),
+		Index:       index,
+		Address:     log.Address.String(),
+		Data:        log.Data,
+	}
+
+	topics := []*string{&result.Topic0, &result.Topic1, &result.Topic2, &result.Topic3}
+	for i, v := range log.Topics {
+		if i < len(topics) {
+			*topics[i] = v.String()
+		}
+	}
+
+	return result
+}
+
+func newTrace(epochNumber uint64, trace *types.LocalizedTrace) (Trace, error) {
+	action, err := json.Marshal(trace.Action)
+	if err != nil {
+		return Trace{}, err
+	}
+
+	return Trace{
+		EpochNumber: epochNumber,
+		BlockHash:   trace.BlockHash.String(),
+		TxHash:      trace.TransactionHash.String(),
+		TxPosition:  uint64(trace.TransactionPosition),
+		Type:        string(trace.Type),
+		Valid:       trace.Valid,
+		Action:      action,
+	}, nil
+}
+
+func bigToString(value *hexutil.Big) string {
+	if value == nil {
+		return ""
+	}
+
+	return value.ToInt().String()
+}
+
+func bigToUint64(value *hexutil.Big) uint64 {
+	if value == nil {
+		return 0
+	}
+
+	return value.ToInt().Uint64()
+}
+
+func addressToString(addr *types.Address) *string {
+	if addr == nil {
+		return nil
+	}
+
+	str := addr.String()
+
+	return &str
+}
+
+func hexToBytes(value string) []byte {
+	if !strings.HasPrefix(value, "0x") {
+		return []byte(value)
+	}
+
+	bytes, err := hexutil.Decode(value)
+	if err != nil {
+		return []byte(value)
+	}
+
+	return bytes
+}
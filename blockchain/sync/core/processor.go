@@ -0,0 +1,204 @@
+package core
+
+import (
+	"github.com/Conflux-Chain/go-conflux-util/blockchain/sync/process/db"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+var ModuleName = "sync.core"
+
+var (
+	_ db.RevertableProcessor[EpochData] = (*Processor)(nil)
+	_ db.BatchProcessor[EpochData]      = (*Processor)(nil)
+	_ db.PipelineBatchProcessor         = (*Processor)(nil)
+	_ db.RangeRevertableProcessor       = (*Processor)(nil)
+)
+
+// Processor persists core space epochs, blocks, transactions, receipts, logs and traces into the pre-defined
+// database models, which implements both db.RevertableProcessor[EpochData] and db.BatchProcessor[EpochData]
+// interfaces.
+//
+// Note, all models are keyed by epoch number, so that the whole epoch will be deleted when reverted.
+type Processor struct {
+	batch models
+}
+
+// NewProcessor creates a new processor, and creates the database tables if absent.
+func NewProcessor(db *gorm.DB) (*Processor, error) {
+	if err := db.AutoMigrate(Models()...); err != nil {
+		return nil, errors.WithMessage(err, "Failed to create core space tables")
+	}
+
+	return &Processor{}, nil
+}
+
+// Process implements the db.Processor[EpochData] interface.
+func (processor *Processor) Process(data EpochData) db.Operation {
+	var models models
+	if err := models.add(data); err != nil {
+		return db.OperationFunc(func(tx *gorm.DB) error {
+			return err
+		})
+	}
+
+	return &models
+}
+
+// Revert implements the db.RevertableProcessor[EpochData] interface.
+func (processor *Processor) Revert(data EpochData) db.Operation {
+	epochNumber := data.Blocks[len(data.Blocks)-1].EpochNumber.ToInt().Uint64()
+
+	return db.ComposeOperation(
+		db.DeleteOperation(&Trace{}, "epoch_number >= ?", epochNumber),
+		db.DeleteOperation(&Log{}, "epoch_number >= ?", epochNumber),
+		db.DeleteOperation(&Receipt{}, "epoch_number >= ?", epochNumber),
+		db.DeleteOperation(&Transaction{}, "epoch_number >= ?", epochNumber),
+		db.DeleteOperation(&Block{}, "epoch_number >= ?", epochNumber),
+		db.DeleteOperation(&Epoch{}, "number >= ?", epochNumber),
+	)
+}
+
+// RevertRange implements the db.RangeRevertableProcessor interface.
+func (processor *Processor) RevertRange(fromEpochNumber, toEpochNumber uint64) db.Operation {
+	return db.ComposeOperation(
+		db.DeleteOperation(&Trace{}, "epoch_number BETWEEN ? AND ?", fromEpochNumber, toEpochNumber),
+		db.DeleteOperation(&Log{}, "epoch_number BETWEEN ? AND ?", fromEpochNumber, toEpochNumber),
+		db.DeleteOperation(&Receipt{}, "epoch_number BETWEEN ? AND ?", fromEpochNumber, toEpochNumber),
+		db.DeleteOperation(&Transaction{}, "epoch_number BETWEEN ? AND ?", fromEpochNumber, toEpochNumber),
+		db.DeleteOperation(&Block{}, "epoch_number BETWEEN ? AND ?", fromEpochNumber, toEpochNumber),
+		db.DeleteOperation(&Epoch{}, "number BETWEEN ? AND ?", fromEpochNumber, toEpochNumber),
+	)
+}
+
+// BatchProcess implements the db.BatchProcessor[EpochData] interface.
+func (processor *Processor) BatchProcess(data EpochData) int {
+	if err := processor.batch.add(data); err != nil && processor.batch.err == nil {
+		processor.batch.err = err
+	}
+
+	return processor.batch.size()
+}
+
+// BatchExec implements the db.BatchProcessor[EpochData] interface.
+func (processor *Processor) BatchExec(tx *gorm.DB, createBatchSize int) error {
+	return processor.batch.exec(tx, createBatchSize)
+}
+
+// BatchReset implements the db.BatchProcessor[EpochData] interface.
+func (processor *Processor) BatchReset() {
+	processor.batch = models{}
+}
+
+// BatchDetach implements the db.PipelineBatchProcessor interface.
+func (processor *Processor) BatchDetach(createBatchSize int) db.Operation {
+	batch := processor.batch
+	processor.batch = models{}
+
+	return db.OperationFunc(func(tx *gorm.DB) error {
+		return batch.exec(tx, createBatchSize)
+	})
+}
+
+// models is the database models to create, which implements the db.Operation interface.
+type models struct {
+	epochs   []Epoch
+	blocks   []Block
+	txs      []Transaction
+	receipts []Receipt
+	logs     []Log
+	traces   []Trace
+	err      error // failed to convert any data into models
+}
+
+// add converts the given epoch data into database models.
+func (m *models) add(data EpochData) error {
+	pivotBlock := data.Blocks[len(data.Blocks)-1]
+	epochNumber := pivotBlock.EpochNumber.ToInt().Uint64()
+
+	m.epochs = append(m.epochs, Epoch{
+		Number:    epochNumber,
+		PivotHash: pivotBlock.Hash.String(),
+		Timestamp: bigToUint64(pivotBlock.Timestamp),
+		NumBlocks: len(data.Blocks),
+		NumTxs:    data.numTxs,
+	})
+
+	for i, block := range data.Blocks {
+		m.blocks = append(m.blocks, newBlock(epochNumber, i, block))
+
+		for j := range block.Transactions {
+			m.txs = append(m.txs, newTransaction(epochNumber, block, j, &block.Transactions[j]))
+		}
+	}
+
+	var logIndex uint64
+
+	for _, blockReceipts := range data.Receipts {
+		for i := range blockReceipts {
+			receipt := &blockReceipts[i]
+			m.receipts = append(m.receipts, newReceipt(epochNumber, receipt))
+
+			for j := range receipt.Logs {
+				m.logs = append(m.logs, newLog(epochNumber, logIndex, receipt, &receipt.Logs[j]))
+				logIndex++
+			}
+		}
+	}
+
+	if data.Traces == nil {
+		return nil
+	}
+
+	for _, v := range data.Traces.CfxTraces {
+		trace, err := newTrace(epochNumber, v)
+		if err != nil {
+			return errors.WithMessagef(err, "Failed to convert trace of epoch %v", epochNumber)
+		}
+
+		m.traces = append(m.traces, trace)
+	}
+
+	return nil
+}
+
+func (m *models) size() int {
+	return len(m.epochs) + len(m.blocks) + len(m.txs) + len(m.receipts) + len(m.logs) + len(m.traces)
+}
+
+// Exec implements the db.Operation interface.
+func (m *models) Exec(tx *gorm.DB) error {
+	return m.exec(tx, 0)
+}
+
+func (m *models) exec(tx *gorm.DB, createBatchSize int) error {
+	if m.err != nil {
+		return m.err
+	}
+
+	if err := db.CreateInBatches(tx, m.epochs, createBatchSize); err != nil {
+		return errors.WithMessage(err, "Failed to create epochs")
+	}
+
+	if err := db.CreateInBatches(tx, m.blocks, createBatchSize); err != nil {
+		return errors.WithMessage(err, "Failed to create blocks")
+	}
+
+	if err := db.CreateInBatches(tx, m.txs, createBatchSize); err != nil {
+		return errors.WithMessage(err, "Failed to create transactions")
+	}
+
+	if err := db.CreateInBatches(tx, m.receipts, createBatchSize); err != nil {
+		return errors.WithMessage(err, "Failed to create receipts")
+	}
+
+	if err := db.CreateInBatches(tx, m.logs, createBatchSize); err != nil {
+		return errors.WithMessage(err, "Failed to create logs")
+	}
+
+	if err := db.CreateInBatches(tx, m.traces, createBatchSize); err != nil {
+		return errors.WithMessage(err, "Failed to create traces")
+	}
+
+	return nil
+}
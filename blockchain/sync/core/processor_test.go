@@ -0,0 +1,149 @@
+package core
+
+import (
+	"fmt"
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/Conflux-Chain/go-conflux-sdk/types/cfxaddress"
+	"github.com/Conflux-Chain/go-conflux-util/store"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+var testAccount = cfxaddress.MustNewFromHex("0x1000000000000000000000000000000000000001", 1029)
+
+func newTestHash(prefix string, n uint64) types.Hash {
+	return types.Hash(fmt.Sprintf("0x%v%063x", prefix, n))
+}
+
+// newTestEpochData returns an epoch that contains 2 blocks, and each block contains 1 transaction.
+func newTestEpochData(epochNumber uint64) EpochData {
+	epoch := (*hexutil.Big)(new(big.Int).SetUint64(epochNumber))
+
+	var data EpochData
+
+	for i := uint64(0); i < 2; i++ {
+		block := types.Block{
+			BlockHeader: types.BlockHeader{
+				Hash:        newTestHash("b", epochNumber*10+i),
+				EpochNumber: epoch,
+				Miner:       testAccount,
+			},
+			Transactions: []types.Transaction{
+				{Hash: newTestHash("c", epochNumber*10+i), From: testAccount},
+			},
+		}
+
+		data.Blocks = append(data.Blocks, &block)
+		data.Receipts = append(data.Receipts, []types.TransactionReceipt{{
+			TransactionHash: block.Transactions[0].Hash,
+			BlockHash:       block.Hash,
+			From:            testAccount,
+			Logs:            []types.Log{{Address: testAccount}},
+		}})
+		data.numTxs++
+	}
+
+	data.Traces = &types.EpochTrace{
+		CfxTraces: []*types.LocalizedTrace{{Type: types.TRACE_CALL, TransactionHash: data.Receipts[0][0].TransactionHash}},
+	}
+
+	return data
+}
+
+func mustCount(t *testing.T, db *gorm.DB, model any) int64 {
+	var count int64
+	assert.NoError(t, db.Model(model).Count(&count).Error)
+	return count
+}
+
+func assertCounts(t *testing.T, db *gorm.DB, epochs int64) {
+	assert.Equal(t, epochs, mustCount(t, db, &Epoch{}))
+	assert.Equal(t, epochs*2, mustCount(t, db, &Block{}))
+	assert.Equal(t, epochs*2, mustCount(t, db, &Transaction{}))
+	assert.Equal(t, epochs*2, mustCount(t, db, &Receipt{}))
+	assert.Equal(t, epochs*2, mustCount(t, db, &Log{}))
+	assert.Equal(t, epochs, mustCount(t, db, &Trace{}))
+}
+
+func TestProcessor(t *testing.T) {
+	storeConfig := store.NewMemoryConfig()
+	storeConfig.Sqlite.Path = filepath.Join(t.TempDir(), "core.db")
+	DB := storeConfig.MustOpenOrCreate()
+
+	processor, err := NewProcessor(DB)
+	assert.NoError(t, err)
+
+	// catch up in batch
+	for i := uint64(1); i <= 3; i++ {
+		processor.BatchProcess(newTestEpochData(i))
+	}
+	assert.NoError(t, processor.BatchExec(DB, 2))
+	processor.BatchReset()
+
+	// latest
+	assert.NoError(t, processor.Process(newTestEpochData(4)).Exec(DB))
+	assertCounts(t, DB, 4)
+
+	var blocks []Block
+	assert.NoError(t, DB.Where("epoch_number = ?", 4).Order("`index` ASC").Find(&blocks).Error)
+	assert.Equal(t, 2, len(blocks))
+	assert.Equal(t, string(newTestHash("b", 41)), blocks[1].Hash) // pivot block
+
+	// revert the whole epochs 3 and 4
+	assert.NoError(t, processor.Revert(newTestEpochData(3)).Exec(DB))
+	assertCounts(t, DB, 2)
+}
+
+func TestProcessorBatchDetach(t *testing.T) {
+	storeConfig := store.NewMemoryConfig()
+	storeConfig.Sqlite.Path = filepath.Join(t.TempDir(), "core.db")
+	DB := storeConfig.MustOpenOrCreate()
+
+	processor, err := NewProcessor(DB)
+	assert.NoError(t, err)
+
+	for i := uint64(1); i <= 3; i++ {
+		processor.BatchProcess(newTestEpochData(i))
+	}
+	op := processor.BatchDetach(2)
+
+	// build the next batch while writing the detached one
+	processor.BatchProcess(newTestEpochData(4))
+	assert.NoError(t, op.Exec(DB))
+	assertCounts(t, DB, 3)
+
+	assert.NoError(t, processor.BatchDetach(2).Exec(DB))
+	assertCounts(t, DB, 4)
+}
+
+func TestProcessorInvalidTrace(t *testing.T) {
+	storeConfig := store.NewMemoryConfig()
+	storeConfig.Sqlite.Path = filepath.Join(t.TempDir(), "core.db")
+	DB := storeConfig.MustOpenOrCreate()
+
+	processor, err := NewProcessor(DB)
+	assert.NoError(t, err)
+
+	data := newTestEpochData(2)
+	data.Traces.CfxTraces[0].Action = make(chan int)
+
+	assert.ErrorContains(t, processor.Process(data).Exec(DB), "Failed to convert trace of epoch 2")
+	assert.Equal(t, int64(0), mustCount(t, DB, &Epoch{}))
+
+	// failed to execute batch
+	processor.BatchProcess(newTestEpochData(1))
+	processor.BatchProcess(data)
+	assert.ErrorContains(t, processor.BatchExec(DB, 2), "Failed to convert trace of epoch 2")
+	assert.Equal(t, int64(0), mustCount(t, DB, &Epoch{}))
+
+	// succeeded after reset
+	processor.BatchReset()
+	processor.BatchProcess(newTestEpochData(1))
+	assert.NoError(t, processor.BatchExec(DB, 2))
+	assertCounts(t, DB, 1)
+}
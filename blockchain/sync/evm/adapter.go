@@ -32,6 +32,8 @@ type AdapterOption struct {
 }
 
 var _ poll.Adapter[BlockData] = (*Adapter)(nil)
+var _ poll.Subscriber = (*Adapter)(nil)
+var _ poll.RangeAdapter[BlockData] = (*Adapter)(nil)
 
 // Adapter implements the poll.Adapter[T] interface to poll data from evm RPC.
 type Adapter struct {
@@ -100,19 +102,20 @@ func (adapter *Adapter) GetBlockData(ctx context.Context, blockNumber uint64) (B
 	var data BlockData
 
 	bn := types.BlockNumber(blockNumber)
+	client := adapter.client.WithContext(ctx)
 
-	if err := data.queryBlock(adapter.client, bn); err != nil {
+	if err := data.queryBlock(client, bn); err != nil {
 		return BlockData{}, errors.WithMessage(err, "Failed to query block")
 	}
 
 	if !adapter.option.IgnoreReceipts {
-		if err := data.queryReceipts(adapter.client, bn); err != nil {
+		if err := data.queryReceipts(client, bn); err != nil {
 			return BlockData{}, errors.WithMessage(err, "Failed to query receipts")
 		}
 	}
 
 	if !adapter.option.IgnoreTraces {
-		if err := data.queryTraces(adapter.client, bn); err != nil {
+		if err := data.queryTraces(client, bn); err != nil {
 			return BlockData{}, errors.WithMessage(err, "Failed to query traces")
 		}
 	}
@@ -120,6 +123,27 @@ func (adapter *Adapter) GetBlockData(ctx context.Context, blockNumber uint64) (B
 	return data, nil
 }
 
+// GetBlockDataRange implements the poll.RangeAdapter[T] interface via JSON-RPC batch requests.
+func (adapter *Adapter) GetBlockDataRange(ctx context.Context, from, to uint64) ([]BlockData, error) {
+	if from > to {
+		return nil, errors.Errorf("Invalid block range [%v, %v]", from, to)
+	}
+
+	return batchQueryBlockData(ctx, adapter.client, from, to, adapter.option)
+}
+
+// SubscribeNewBlocks implements the poll.Subscriber interface, which requires a websocket URL.
+func (adapter *Adapter) SubscribeNewBlocks(ctx context.Context) (<-chan struct{}, error) {
+	headCh := make(chan *types.Header, 16)
+
+	sub, err := adapter.client.Eth.SubscribeNewHead(headCh)
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to subscribe new heads")
+	}
+
+	return poll.NewNotifyChannel(ctx, sub, headCh), nil
+}
+
 // GetBlockHash implements the poll.Adapter[T] interface.
 func (adapter *Adapter) GetBlockHash(data BlockData) string {
 	return data.Block.Hash.Hex()
@@ -1,7 +1,10 @@
 package evm
 
 import (
+	"context"
+
 	"github.com/DmitriyVTitov/size"
+	rpc "github.com/openweb3/go-rpc-provider"
 	"github.com/openweb3/web3go"
 	"github.com/openweb3/web3go/types"
 	"github.com/pkg/errors"
@@ -18,6 +21,11 @@ func (data BlockData) Size() int {
 	return size.Of(data)
 }
 
+// BlockNumber implements the db.Numbered interface.
+func (data BlockData) BlockNumber() uint64 {
+	return data.Block.Number.Uint64()
+}
+
 func (data *BlockData) queryBlock(client *web3go.Client, blockNumber types.BlockNumber) error {
 	block, err := client.Eth.BlockByNumber(blockNumber, true)
 	if err != nil {
@@ -46,8 +54,14 @@ func (data *BlockData) queryReceipts(client *web3go.Client, blockNumber types.Bl
 		return errors.WithMessage(err, "Failed to get block receipts by block number")
 	}
 
+	return data.setReceipts(receipts)
+}
+
+func (data *BlockData) setReceipts(receipts []*types.Receipt) error {
+	txs := data.Block.Transactions.Transactions()
+
 	if receipts == nil {
-		return errors.Errorf("Receipts not found by block %v", blockNumber.Int64())
+		return errors.Errorf("Receipts not found by block %v", data.Block.Number)
 	}
 
 	// detect temp chain reorg
@@ -83,8 +97,12 @@ func (data *BlockData) queryTraces(client *web3go.Client, blockNumber types.Bloc
 		return errors.WithMessage(err, "Failed to get block traces by block number")
 	}
 
+	return data.setTraces(traces)
+}
+
+func (data *BlockData) setTraces(traces []types.LocalizedTrace) error {
 	if traces == nil {
-		return errors.Errorf("Traces not found by block %v", blockNumber.Int64())
+		return errors.Errorf("Traces not found by block %v", data.Block.Number)
 	}
 
 	// Try to detect temp chain reorg if there is any trace.
@@ -99,3 +117,134 @@ func (data *BlockData) queryTraces(client *web3go.Client, blockNumber types.Bloc
 
 	return nil
 }
+
+// batchQueryBlocks queries blocks in range [from, to] via JSON-RPC batch request.
+func batchQueryBlocks(ctx context.Context, client *web3go.Client, from, to uint64, isFull bool) ([]*types.Block, error) {
+	blocks := make([]*types.Block, to-from+1)
+	batch := make([]rpc.BatchElem, 0, len(blocks))
+
+	for i := range blocks {
+		blocks[i] = &types.Block{}
+		blocks[i].Transactions = *types.NewTxOrHashList(isFull)
+
+		batch = append(batch, rpc.BatchElem{
+			Method: "eth_getBlockByNumber",
+			Args:   []any{types.BlockNumber(from + uint64(i)), isFull},
+			Result: &blocks[i],
+		})
+	}
+
+	if err := client.Provider().BatchCallContext(ctx, batch); err != nil {
+		return nil, errors.WithMessage(err, "Failed to batch get blocks by number")
+	}
+
+	for i, v := range batch {
+		bn := from + uint64(i)
+
+		if v.Error != nil {
+			return nil, errors.WithMessagef(v.Error, "Failed to get block by number %v", bn)
+		}
+
+		if blocks[i] == nil {
+			return nil, errors.Errorf("Block not found by number %v", bn)
+		}
+
+		// detect temp chain reorg
+		if i > 0 && blocks[i].ParentHash != blocks[i-1].Hash {
+			return nil, errors.Errorf("Block parent hash mismatch, block = %v", bn)
+		}
+	}
+
+	return blocks, nil
+}
+
+// batchQueryBlockData queries the whole blockchain data in range [from, to] via JSON-RPC batch requests.
+func batchQueryBlockData(ctx context.Context, client *web3go.Client, from, to uint64, option AdapterOption) ([]BlockData, error) {
+	blocks, err := batchQueryBlocks(ctx, client, from, to, true)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]BlockData, 0, len(blocks))
+	for _, v := range blocks {
+		result = append(result, BlockData{Block: v})
+	}
+
+	if !option.IgnoreReceipts {
+		receipts := make([][]*types.Receipt, len(result))
+		resultPtrs := make([]any, len(result))
+		for i := range receipts {
+			resultPtrs[i] = &receipts[i]
+		}
+
+		if err = batchCallNonEmptyBlocks(ctx, client, result, "eth_getBlockReceipts", resultPtrs); err != nil {
+			return nil, errors.WithMessage(err, "Failed to batch get block receipts")
+		}
+
+		for i := range result {
+			if len(result[i].Block.Transactions.Transactions()) == 0 {
+				result[i].Receipts = []*types.Receipt{}
+			} else if err = result[i].setReceipts(receipts[i]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if !option.IgnoreTraces {
+		traces := make([][]types.LocalizedTrace, len(result))
+		resultPtrs := make([]any, len(result))
+		for i := range traces {
+			resultPtrs[i] = &traces[i]
+		}
+
+		if err = batchCallNonEmptyBlocks(ctx, client, result, "trace_block", resultPtrs); err != nil {
+			return nil, errors.WithMessage(err, "Failed to batch get block traces")
+		}
+
+		for i := range result {
+			if len(result[i].Block.Transactions.Transactions()) == 0 {
+				result[i].Traces = []types.LocalizedTrace{}
+			} else if err = result[i].setTraces(traces[i]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// batchCallNonEmptyBlocks calls the given RPC method by block number via JSON-RPC batch request, and ignores
+// the blocks without any transaction.
+func batchCallNonEmptyBlocks(ctx context.Context, client *web3go.Client, data []BlockData, method string, resultPtrs []any) error {
+	var batch []rpc.BatchElem
+
+	for i, v := range data {
+		if len(v.Block.Transactions.Transactions()) == 0 {
+			continue
+		}
+
+		bnoh := types.BlockNumberOrHashWithNumber(types.BlockNumber(v.Block.Number.Int64()))
+
+		batch = append(batch, rpc.BatchElem{
+			Method: method,
+			Args:   []any{bnoh},
+			Result: resultPtrs[i],
+		})
+	}
+
+	if len(batch) == 0 {
+		return nil
+	}
+
+	if err := client.Provider().BatchCallContext(ctx, batch); err != nil {
+		return errors.WithMessage(err, "Failed to batch call RPC")
+	}
+
+	for _, v := range batch {
+		if v.Error != nil {
+			return errors.WithMessagef(v.Error, "Failed to call RPC %v with args %v", method, v.Args)
+		}
+	}
+
+	return nil
+}
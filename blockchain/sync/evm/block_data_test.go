@@ -0,0 +1,187 @@
+package evm
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/Conflux-Chain/go-conflux-util/blockchain/sync/poll/testutil"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func testTxHash(blockNumber uint64) common.Hash {
+	return common.BigToHash(new(big.Int).SetUint64(blockNumber + 2000))
+}
+
+// newTestRPCFullBlock returns a block with transaction details in JSON-RPC format, which includes one transaction
+// for even block number only.
+func newTestRPCFullBlock(blockNumber uint64) map[string]any {
+	block := newTestRPCBlock(blockNumber)
+
+	txs := []map[string]any{}
+	if blockNumber%2 == 0 {
+		txs = append(txs, map[string]any{
+			"hash":  testTxHash(blockNumber),
+			"gas":   "0x0",
+			"input": "0x",
+			"nonce": "0x0",
+			"r":     "0x0",
+			"s":     "0x0",
+			"v":     "0x0",
+			"value": "0x0",
+		})
+	}
+
+	block["transactions"] = txs
+
+	return block
+}
+
+// testBlockRPCHandler returns a RPC handler that serves blocks and receipts till the given latest block number.
+func testBlockRPCHandler(latest uint64) testutil.RPCHandler {
+	return func(method string, params []json.RawMessage) (any, error) {
+		bn, err := parseBlockNumber(params)
+		if err != nil {
+			return nil, err
+		}
+
+		if bn > latest {
+			return nil, nil
+		}
+
+		switch method {
+		case "eth_getBlockByNumber":
+			var isFull bool
+			if err = json.Unmarshal(params[1], &isFull); err != nil {
+				return nil, err
+			}
+
+			if isFull {
+				return newTestRPCFullBlock(bn), nil
+			}
+
+			return newTestRPCBlock(bn), nil
+		case "eth_getBlockReceipts":
+			return []any{map[string]any{
+				"transactionHash": testTxHash(bn),
+				"blockHash":       testBlockHash(bn),
+				"blockNumber":     hexUint64(bn),
+				"status":          "0x1",
+				"logs":            []any{},
+			}}, nil
+		default:
+			return nil, errors.Errorf("Unsupported method %v", method)
+		}
+	}
+}
+
+func TestBatchQueryBlocks(t *testing.T) {
+	server := newTestRPCServer(t, testBlockRPCHandler(10))
+
+	adapter, err := NewAdapter(server.URL, AdapterOption{})
+	assert.NoError(t, err)
+	defer adapter.Close()
+
+	// responses of batch request are in reversed order
+	blocks, err := batchQueryBlocks(context.Background(), adapter.client, 3, 7, false)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, len(blocks))
+
+	for i, v := range blocks {
+		assert.Equal(t, uint64(3+i), v.Number.Uint64())
+		assert.Equal(t, testBlockHash(uint64(3+i)), v.Hash)
+	}
+
+	assert.Equal(t, 5, len(server.Requests("eth_getBlockByNumber")))
+
+	// range crosses the latest block
+	_, err = batchQueryBlocks(context.Background(), adapter.client, 8, 12, false)
+	assert.ErrorContains(t, err, "Block not found by number 11")
+}
+
+func TestBatchQueryBlocksPartialError(t *testing.T) {
+	handler := testBlockRPCHandler(10)
+	server := newTestRPCServer(t, func(method string, params []json.RawMessage) (any, error) {
+		if bn, _ := parseBlockNumber(params); bn == 5 {
+			return nil, errors.New("mock error")
+		}
+
+		return handler(method, params)
+	})
+
+	adapter, err := NewAdapter(server.URL, AdapterOption{})
+	assert.NoError(t, err)
+	defer adapter.Close()
+
+	_, err = batchQueryBlocks(context.Background(), adapter.client, 3, 7, false)
+	assert.ErrorContains(t, err, "Failed to get block by number 5")
+	assert.ErrorContains(t, err, "mock error")
+}
+
+func TestBatchQueryBlocksReorg(t *testing.T) {
+	handler := testBlockRPCHandler(10)
+	server := newTestRPCServer(t, func(method string, params []json.RawMessage) (any, error) {
+		result, err := handler(method, params)
+
+		// block 6 is on another fork
+		if bn, _ := parseBlockNumber(params); bn == 6 && err == nil {
+			result.(map[string]any)["parentHash"] = common.HexToHash("0x06")
+		}
+
+		return result, err
+	})
+
+	adapter, err := NewAdapter(server.URL, AdapterOption{})
+	assert.NoError(t, err)
+	defer adapter.Close()
+
+	_, err = batchQueryBlocks(context.Background(), adapter.client, 3, 7, false)
+	assert.ErrorContains(t, err, "Block parent hash mismatch, block = 6")
+}
+
+func TestAdapterGetBlockDataRange(t *testing.T) {
+	server := newTestRPCServer(t, testBlockRPCHandler(10))
+
+	adapter, err := NewAdapter(server.URL, AdapterOption{IgnoreTraces: true})
+	assert.NoError(t, err)
+	defer adapter.Close()
+
+	data, err := adapter.GetBlockDataRange(context.Background(), 1, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, len(data))
+
+	for i, v := range data {
+		bn := uint64(1 + i)
+		assert.Equal(t, bn, v.BlockNumber())
+		assert.Nil(t, v.Traces)
+
+		if bn%2 == 0 {
+			assert.Equal(t, 1, len(v.Receipts))
+			assert.Equal(t, testTxHash(bn), v.Receipts[0].TransactionHash)
+		} else {
+			assert.NotNil(t, v.Receipts)
+			assert.Empty(t, v.Receipts)
+		}
+	}
+
+	// receipts are queried for non-empty blocks only
+	var blockNumbers []types.BlockNumberOrHash
+	for _, v := range server.Requests("eth_getBlockReceipts") {
+		var bnoh types.BlockNumberOrHash
+		assert.NoError(t, json.Unmarshal(v.Params[0], &bnoh))
+		blockNumbers = append(blockNumbers, bnoh)
+	}
+
+	assert.ElementsMatch(t, []types.BlockNumberOrHash{
+		types.BlockNumberOrHashWithNumber(2),
+		types.BlockNumberOrHashWithNumber(4),
+	}, blockNumbers)
+
+	// invalid range
+	_, err = adapter.GetBlockDataRange(context.Background(), 5, 4)
+	assert.ErrorContains(t, err, "Invalid block range")
+}
@@ -0,0 +1,358 @@
+package evm
+
+import (
+	"encoding/json"
+
+	"github.com/Conflux-Chain/go-conflux-util/blockchain/sync/core"
+	"github.com/Conflux-Chain/go-conflux-util/blockchain/sync/process/db"
+	"github.com/Conflux-Chain/go-conflux-util/log"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// Spaces of decoded event logs, which share the same EventLog table.
+const (
+	SpaceEVM  = "evm"
+	SpaceCore = "core"
+)
+
+// EventLog is the database model of decoded event log, in which arguments are persisted in JSON format.
+//
+// Note, for core space, block number is the epoch number and address is in base32 format.
+type EventLog struct {
+	ID          uint64
+	Space       string `gorm:"size:8;not null;index:idx_evm_event_logs_space_block,priority:1"` // SpaceEVM or SpaceCore
+	BlockNumber uint64 `gorm:"not null;index:idx_evm_event_logs_space_block,priority:2"`
+	BlockHash   string `gorm:"size:66;not null"`
+	TxHash      string `gorm:"size:66;not null;index"`
+	LogIndex    uint64 `gorm:"not null"`
+	Address     string `gorm:"size:64;not null;index"`
+	Contract    string `gorm:"size:64;not null;index:idx_contract_event,priority:1"`
+	Event       string `gorm:"size:64;not null;index:idx_contract_event,priority:2"`
+	Args        string `gorm:"type:text"`
+}
+
+func (EventLog) TableName() string {
+	return "evm_event_logs"
+}
+
+// Event is a decoded event log.
+type Event struct {
+	Contract    string // contract name
+	Name        string // event name
+	Address     string // contract address, which is in base32 format for core space
+	BlockNumber uint64 // epoch number for core space
+	BlockHash   string
+	TxHash      string
+	LogIndex    uint64
+	Args        map[string]any // both indexed and non-indexed arguments
+}
+
+// TypedEvent converts the decoded event into a typed database model.
+type TypedEvent struct {
+	// Model is a pointer of the database model, e.g. &Transfer{}, which must have a "block_number" column
+	// to delete data when reverted.
+	Model any
+
+	// Convert converts the decoded event into a database model to create.
+	Convert func(event Event) any
+}
+
+// Contract defines the event logs to decode.
+type Contract struct {
+	Name      string
+	ABI       abi.ABI
+	Addresses []common.Address // hex addresses to decode event logs, and empty indicates any address
+
+	// Typed is optional to persist event in typed database model rather than EventLog, keyed by event name.
+	Typed map[string]TypedEvent
+}
+
+// rawLog is the event log of eSpace or core space.
+type rawLog struct {
+	address     common.Address
+	addressStr  string
+	topics      []common.Hash
+	data        []byte
+	blockNumber uint64
+	blockHash   string
+	txHash      string
+	logIndex    uint64
+}
+
+var (
+	_ db.RevertableProcessor[BlockData]      = (*EventProcessor[BlockData])(nil)
+	_ db.RevertableProcessor[core.EpochData] = (*EventProcessor[core.EpochData])(nil)
+	_ db.RangeRevertableProcessor            = (*EventProcessor[BlockData])(nil)
+)
+
+// EventProcessor decodes event logs in receipts with contract ABIs, and persists the decoded events in database.
+// It implements the db.RevertableProcessor[T] interface, and supports both eSpace and core space.
+type EventProcessor[T any] struct {
+	space       string // SpaceEVM or SpaceCore
+	contracts   []Contract
+	models      []any // typed models to delete when reverted
+	logs        func(data T) []rawLog
+	blockNumber func(data T) uint64
+}
+
+// NewEventProcessor creates a new processor to decode eSpace event logs, and creates the database tables if absent.
+func NewEventProcessor(db *gorm.DB, contracts ...Contract) (*EventProcessor[BlockData], error) {
+	return newEventProcessor(db, SpaceEVM, contracts, blockLogs, func(data BlockData) uint64 {
+		return data.Block.Number.Uint64()
+	})
+}
+
+// NewCoreEventProcessor creates a new processor to decode core space event logs, and creates the database tables
+// if absent.
+func NewCoreEventProcessor(db *gorm.DB, contracts ...Contract) (*EventProcessor[core.EpochData], error) {
+	return newEventProcessor(db, SpaceCore, contracts, epochLogs, func(data core.EpochData) uint64 {
+		return data.Blocks[len(data.Blocks)-1].EpochNumber.ToInt().Uint64()
+	})
+}
+
+func newEventProcessor[T any](
+	db *gorm.DB, space string, contracts []Contract, logs func(data T) []rawLog, blockNumber func(data T) uint64,
+) (*EventProcessor[T], error) {
+	models := []any{&EventLog{}}
+
+	for _, contract := range contracts {
+		for name, v := range contract.Typed {
+			if _, ok := contract.ABI.Events[name]; !ok {
+				return nil, errors.Errorf("Event %v not found in ABI of contract %v", name, contract.Name)
+			}
+
+			models = append(models, v.Model)
+		}
+	}
+
+	if err := db.AutoMigrate(models...); err != nil {
+		return nil, errors.WithMessage(err, "Failed to create event tables")
+	}
+
+	return &EventProcessor[T]{
+		space:       space,
+		contracts:   contracts,
+		models:      models,
+		logs:        logs,
+		blockNumber: blockNumber,
+	}, nil
+}
+
+// Process implements the db.Processor[T] interface.
+func (processor *EventProcessor[T]) Process(data T) db.Operation {
+	var models []any
+
+	for _, v := range processor.logs(data) {
+		if model, ok := processor.decode(v); ok {
+			models = append(models, model)
+		}
+	}
+
+	return db.CreateOperation(models...)
+}
+
+// Revert implements the db.RevertableProcessor[T] interface.
+func (processor *EventProcessor[T]) Revert(data T) db.Operation {
+	blockNumber := processor.blockNumber(data)
+
+	return processor.deleteOperation("block_number >= ?", blockNumber)
+}
+
+// RevertRange implements the db.RangeRevertableProcessor interface.
+func (processor *EventProcessor[T]) RevertRange(fromBlockNumber, toBlockNumber uint64) db.Operation {
+	return processor.deleteOperation("block_number BETWEEN ? AND ?", fromBlockNumber, toBlockNumber)
+}
+
+// deleteOperation deletes data of all models with the given block number condition. Note, event logs of the other
+// space in the same EventLog table are never deleted.
+func (processor *EventProcessor[T]) deleteOperation(query string, args ...any) db.Operation {
+	ops := make([]db.Operation, 0, len(processor.models))
+
+	for _, v := range processor.models {
+		if _, ok := v.(*EventLog); ok {
+			ops = append(ops, db.DeleteOperation(v, append([]any{"space = ? AND " + query, processor.space}, args...)...))
+		} else {
+			ops = append(ops, db.DeleteOperation(v, append([]any{query}, args...)...))
+		}
+	}
+
+	return db.ComposeOperation(ops...)
+}
+
+// decode decodes the given event log with the first matched contract, and returns the database model to create.
+func (processor *EventProcessor[T]) decode(raw rawLog) (any, bool) {
+	if len(raw.topics) == 0 {
+		return nil, false
+	}
+
+	for _, contract := range processor.contracts {
+		if !contract.matches(raw.address) {
+			continue
+		}
+
+		event, err := contract.ABI.EventByID(raw.topics[0])
+		if err != nil {
+			continue
+		}
+
+		args, err := decodeArgs(event, raw)
+		if err != nil {
+			logger := log.WithModule(ModuleName).WithError(err).WithFields(logrus.Fields{
+				"contract": contract.Name,
+				"event":    event.Name,
+				"address":  raw.addressStr,
+				"tx":       raw.txHash,
+				"index":    raw.logIndex,
+			})
+
+			// Events of any address may share the same signature but different indexed arguments,
+			// e.g. Transfer of ERC20 and ERC721, which is expected to fail to decode.
+			if len(contract.Addresses) == 0 {
+				logger.Debug("Failed to decode event log")
+			} else {
+				logger.Warn("Failed to decode event log")
+			}
+
+			continue
+		}
+
+		decoded := Event{
+			Contract:    contract.Name,
+			Name:        event.Name,
+			Address:     raw.addressStr,
+			BlockNumber: raw.blockNumber,
+			BlockHash:   raw.blockHash,
+			TxHash:      raw.txHash,
+			LogIndex:    raw.logIndex,
+			Args:        args,
+		}
+
+		if typed, ok := contract.Typed[event.Name]; ok {
+			return typed.Convert(decoded), true
+		}
+
+		encoded, err := json.Marshal(args)
+		if err != nil {
+			log.WithModule(ModuleName).WithError(err).WithFields(logrus.Fields{
+				"contract": contract.Name,
+				"event":    event.Name,
+				"tx":       raw.txHash,
+				"index":    raw.logIndex,
+			}).Warn("Failed to marshal event arguments")
+			continue
+		}
+
+		return &EventLog{
+			Space:       processor.space,
+			BlockNumber: decoded.BlockNumber,
+			BlockHash:   decoded.BlockHash,
+			TxHash:      decoded.TxHash,
+			LogIndex:    decoded.LogIndex,
+			Address:     decoded.Address,
+			Contract:    decoded.Contract,
+			Event:       decoded.Name,
+			Args:        string(encoded),
+		}, true
+	}
+
+	return nil, false
+}
+
+func (contract *Contract) matches(addr common.Address) bool {
+	if len(contract.Addresses) == 0 {
+		return true
+	}
+
+	for _, v := range contract.Addresses {
+		if v == addr {
+			return true
+		}
+	}
+
+	return false
+}
+
+// decodeArgs decodes both indexed and non-indexed arguments of the given event log.
+func decodeArgs(event *abi.Event, raw rawLog) (map[string]any, error) {
+	args := make(map[string]any)
+
+	if len(raw.data) > 0 {
+		if err := event.Inputs.UnpackIntoMap(args, raw.data); err != nil {
+			return nil, errors.WithMessage(err, "Failed to unpack data")
+		}
+	}
+
+	var indexed abi.Arguments
+	for _, v := range event.Inputs {
+		if v.Indexed {
+			indexed = append(indexed, v)
+		}
+	}
+
+	if len(indexed) != len(raw.topics)-1 {
+		return nil, errors.Errorf("Number of topics mismatch, expected = %v, actual = %v", len(indexed)+1, len(raw.topics))
+	}
+
+	if err := abi.ParseTopicsIntoMap(args, indexed, raw.topics[1:]); err != nil {
+		return nil, errors.WithMessage(err, "Failed to parse topics")
+	}
+
+	return args, nil
+}
+
+// blockLogs returns all event logs in eSpace block receipts.
+func blockLogs(data BlockData) []rawLog {
+	var logs []rawLog
+
+	for _, receipt := range data.Receipts {
+		for _, v := range receipt.Logs {
+			logs = append(logs, rawLog{
+				address:     v.Address,
+				addressStr:  v.Address.Hex(),
+				topics:      v.Topics,
+				data:        v.Data,
+				blockNumber: v.BlockNumber,
+				blockHash:   v.BlockHash.Hex(),
+				txHash:      v.TxHash.Hex(),
+				logIndex:    uint64(v.Index),
+			})
+		}
+	}
+
+	return logs
+}
+
+// epochLogs returns all event logs in core space epoch receipts.
+func epochLogs(data core.EpochData) []rawLog {
+	var logs []rawLog
+
+	epochNumber := data.Blocks[len(data.Blocks)-1].EpochNumber.ToInt().Uint64()
+
+	for _, blockReceipts := range data.Receipts {
+		for _, receipt := range blockReceipts {
+			for _, v := range receipt.Logs {
+				topics := make([]common.Hash, 0, len(v.Topics))
+				for _, topic := range v.Topics {
+					topics = append(topics, common.HexToHash(string(topic)))
+				}
+
+				logs = append(logs, rawLog{
+					address:     v.Address.MustGetCommonAddress(),
+					addressStr:  v.Address.String(),
+					topics:      topics,
+					data:        v.Data,
+					blockNumber: epochNumber,
+					blockHash:   receipt.BlockHash.String(),
+					txHash:      receipt.TransactionHash.String(),
+					logIndex:    uint64(len(logs)),
+				})
+			}
+		}
+	}
+
+	return logs
+}
@@ -0,0 +1,194 @@
+package evm
+
+import (
+	"math/big"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	cfxtypes "github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/Conflux-Chain/go-conflux-sdk/types/cfxaddress"
+	"github.com/Conflux-Chain/go-conflux-util/blockchain/sync/core"
+	"github.com/Conflux-Chain/go-conflux-util/store"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/openweb3/web3go/types"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+const testERC20ABI = `[{"anonymous":false,"inputs":[
+	{"indexed":true,"name":"from","type":"address"},
+	{"indexed":true,"name":"to","type":"address"},
+	{"indexed":false,"name":"value","type":"uint256"}],
+	"name":"Transfer","type":"event"}]`
+
+type testTransfer struct {
+	ID          uint64
+	BlockNumber uint64
+	From        string
+	To          string
+	Value       string
+}
+
+func newTestEventDB(t *testing.T) *gorm.DB {
+	storeConfig := store.NewMemoryConfig()
+	storeConfig.Sqlite.Path = filepath.Join(t.TempDir(), "events.db")
+	return storeConfig.MustOpenOrCreate()
+}
+
+func newTestContract(t *testing.T, typed bool) Contract {
+	parsed, err := abi.JSON(strings.NewReader(testERC20ABI))
+	assert.NoError(t, err)
+
+	contract := Contract{Name: "erc20", ABI: parsed, Addresses: []common.Address{testContract}}
+
+	if typed {
+		contract.Typed = map[string]TypedEvent{
+			"Transfer": {
+				Model: &testTransfer{},
+				Convert: func(event Event) any {
+					return &testTransfer{
+						BlockNumber: event.BlockNumber,
+						From:        event.Args["from"].(common.Address).Hex(),
+						To:          event.Args["to"].(common.Address).Hex(),
+						Value:       event.Args["value"].(*big.Int).String(),
+					}
+				},
+			},
+		}
+	}
+
+	return contract
+}
+
+// newTestTransferLog returns topics and data of a Transfer event log.
+func newTestTransferLog(t *testing.T, contract Contract, value int64) ([]common.Hash, []byte) {
+	event := contract.ABI.Events["Transfer"]
+
+	data, err := event.Inputs.NonIndexed().Pack(big.NewInt(value))
+	assert.NoError(t, err)
+
+	return []common.Hash{event.ID, common.BytesToHash(testAccount.Bytes()), common.BytesToHash(testContract.Bytes())}, data
+}
+
+func newTestEventBlockData(t *testing.T, contract Contract, blockNumber uint64) BlockData {
+	topics, data := newTestTransferLog(t, contract, 100)
+
+	return BlockData{
+		Block: &types.Block{Number: new(big.Int).SetUint64(blockNumber)},
+		Receipts: []*types.Receipt{{Logs: []*types.Log{
+			{Address: testContract, Topics: topics, Data: data, BlockNumber: blockNumber},
+			{Address: testAccount, Topics: topics, Data: data, BlockNumber: blockNumber, Index: 1}, // address mismatch
+		}}},
+	}
+}
+
+func TestEventProcessor(t *testing.T) {
+	DB := newTestEventDB(t)
+	contract := newTestContract(t, false)
+
+	processor, err := NewEventProcessor(DB, contract)
+	assert.NoError(t, err)
+
+	for i := uint64(1); i <= 3; i++ {
+		assert.NoError(t, processor.Process(newTestEventBlockData(t, contract, i)).Exec(DB))
+	}
+
+	var events []EventLog
+	assert.NoError(t, DB.Order("block_number ASC").Find(&events).Error)
+	assert.Equal(t, 3, len(events))
+	assert.Equal(t, "Transfer", events[0].Event)
+	assert.Equal(t, testContract.Hex(), events[0].Address)
+	assert.JSONEq(t, `{"from":"`+testAccount.Hex()+`","to":"`+testContract.Hex()+`","value":100}`, events[0].Args)
+
+	// revert blocks 2 and 3
+	assert.NoError(t, processor.Revert(newTestEventBlockData(t, contract, 2)).Exec(DB))
+	assert.NoError(t, DB.Find(&events).Error)
+	assert.Equal(t, 1, len(events))
+}
+
+func TestEventProcessorTyped(t *testing.T) {
+	DB := newTestEventDB(t)
+	contract := newTestContract(t, true)
+
+	processor, err := NewEventProcessor(DB, contract)
+	assert.NoError(t, err)
+
+	assert.NoError(t, processor.Process(newTestEventBlockData(t, contract, 1)).Exec(DB))
+
+	var transfers []testTransfer
+	assert.NoError(t, DB.Find(&transfers).Error)
+	assert.Equal(t, []testTransfer{{1, 1, testAccount.Hex(), testContract.Hex(), "100"}}, transfers)
+
+	assert.NoError(t, processor.Revert(newTestEventBlockData(t, contract, 1)).Exec(DB))
+	assert.NoError(t, DB.Find(&transfers).Error)
+	assert.Equal(t, 0, len(transfers))
+}
+
+func newTestEventEpochData(t *testing.T, contract Contract, epochNumber int64) core.EpochData {
+	topics, data := newTestTransferLog(t, contract, 100)
+	cfxTopics := make([]cfxtypes.Hash, 0, len(topics))
+	for _, v := range topics {
+		cfxTopics = append(cfxTopics, cfxtypes.Hash(v.Hex()))
+	}
+
+	address := cfxaddress.MustNewFromCommon(testContract, 1029)
+
+	return core.EpochData{
+		Blocks: []*cfxtypes.Block{{BlockHeader: cfxtypes.BlockHeader{EpochNumber: (*hexutil.Big)(big.NewInt(epochNumber))}}},
+		Receipts: [][]cfxtypes.TransactionReceipt{{
+			{Logs: []cfxtypes.Log{{Address: address, Topics: cfxTopics, Data: data}}},
+		}},
+	}
+}
+
+func TestCoreEventProcessor(t *testing.T) {
+	DB := newTestEventDB(t)
+	contract := newTestContract(t, false)
+
+	processor, err := NewCoreEventProcessor(DB, contract)
+	assert.NoError(t, err)
+
+	assert.NoError(t, processor.Process(newTestEventEpochData(t, contract, 5)).Exec(DB))
+
+	var events []EventLog
+	assert.NoError(t, DB.Find(&events).Error)
+	assert.Equal(t, 1, len(events))
+	assert.Equal(t, SpaceCore, events[0].Space)
+	assert.Equal(t, uint64(5), events[0].BlockNumber)
+	assert.Equal(t, cfxaddress.MustNewFromCommon(testContract, 1029).String(), events[0].Address)
+}
+
+func TestEventProcessorSpaces(t *testing.T) {
+	DB := newTestEventDB(t)
+	contract := newTestContract(t, false)
+
+	evmProcessor, err := NewEventProcessor(DB, contract)
+	assert.NoError(t, err)
+
+	coreProcessor, err := NewCoreEventProcessor(DB, contract)
+	assert.NoError(t, err)
+
+	for i := uint64(5); i <= 7; i++ {
+		assert.NoError(t, evmProcessor.Process(newTestEventBlockData(t, contract, i)).Exec(DB))
+		assert.NoError(t, coreProcessor.Process(newTestEventEpochData(t, contract, int64(i))).Exec(DB))
+	}
+
+	countEvents := func(space string) int64 {
+		var count int64
+		assert.NoError(t, DB.Model(&EventLog{}).Where("space = ?", space).Count(&count).Error)
+		return count
+	}
+
+	// revert eSpace only
+	assert.NoError(t, evmProcessor.Revert(newTestEventBlockData(t, contract, 6)).Exec(DB))
+	assert.Equal(t, int64(1), countEvents(SpaceEVM))
+	assert.Equal(t, int64(3), countEvents(SpaceCore))
+
+	// revert range of core space only
+	assert.NoError(t, coreProcessor.RevertRange(5, 6).Exec(DB))
+	assert.Equal(t, int64(1), countEvents(SpaceEVM))
+	assert.Equal(t, int64(1), countEvents(SpaceCore))
+}
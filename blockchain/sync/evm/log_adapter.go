@@ -0,0 +1,157 @@
+package evm
+
+import (
+	"context"
+
+	"github.com/Conflux-Chain/go-conflux-util/blockchain/sync/poll"
+	"github.com/DmitriyVTitov/size"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
+)
+
+// LogFilter is used to filter event logs by contract addresses and/or topics.
+type LogFilter struct {
+	Addresses []common.Address
+
+	// Topics restricts matches to particular event topics, e.g. {{A, B}, {C}} matches topic (A OR B)
+	// in first position AND C in second position. Please refer to types.FilterQuery for more details.
+	Topics [][]common.Hash
+}
+
+type LogData struct {
+	Block *types.Block // always not nil, but without transaction details
+	Logs  []types.Log  // empty slice if no event log matched in block
+}
+
+// Size implements the channel.Sizable interface.
+func (data LogData) Size() int {
+	return size.Of(data)
+}
+
+// BlockNumber implements the db.Numbered interface.
+func (data LogData) BlockNumber() uint64 {
+	return data.Block.Number.Uint64()
+}
+
+var _ poll.Adapter[LogData] = (*LogAdapter)(nil)
+var _ poll.RangeAdapter[LogData] = (*LogAdapter)(nil)
+
+// LogAdapter implements the poll.Adapter[T] interface to poll event logs from evm RPC, which is used for
+// services that only require contract events.
+//
+// Block header is polled along with event logs, so that chain reorg could be detected by block hash.
+type LogAdapter struct {
+	*Adapter
+
+	filter LogFilter
+}
+
+func NewLogAdapter(url string, filter LogFilter, option AdapterOption) (*LogAdapter, error) {
+	adapter, err := NewAdapter(url, option)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LogAdapter{adapter, filter}, nil
+}
+
+func NewLogAdapterWithConfig(config AdapterConfig, filter LogFilter) (*LogAdapter, error) {
+	adapter, err := NewAdapterWithConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LogAdapter{adapter, filter}, nil
+}
+
+// GetBlockData implements the poll.Adapter[T] interface.
+//
+// Event logs are queried by block hash, so as to keep consistent with block header in case of chain reorg.
+func (adapter *LogAdapter) GetBlockData(ctx context.Context, blockNumber uint64) (LogData, error) {
+	client := adapter.client.WithContext(ctx)
+
+	block, err := client.Eth.BlockByNumber(types.BlockNumber(blockNumber), false)
+	if err != nil {
+		return LogData{}, errors.WithMessage(err, "Failed to get block by number")
+	}
+
+	if block == nil {
+		return LogData{}, errors.Errorf("Block not found by number %v", blockNumber)
+	}
+
+	logs, err := client.Eth.Logs(types.FilterQuery{
+		BlockHash: &block.Hash,
+		Addresses: adapter.filter.Addresses,
+		Topics:    adapter.filter.Topics,
+	})
+	if err != nil {
+		return LogData{}, errors.WithMessage(err, "Failed to get logs by block hash")
+	}
+
+	if logs == nil {
+		logs = []types.Log{}
+	}
+
+	return LogData{block, logs}, nil
+}
+
+// GetBlockDataRange implements the poll.RangeAdapter[T] interface. It returns the event logs in range [from, to],
+// along with block headers in batch, which is much faster than GetBlockData block by block.
+//
+// Note, it returns error if any chain reorg detected, so it is recommended for finalized blocks only.
+func (adapter *LogAdapter) GetBlockDataRange(ctx context.Context, from, to uint64) ([]LogData, error) {
+	if from > to {
+		return nil, errors.Errorf("Invalid block range [%v, %v]", from, to)
+	}
+
+	client := adapter.client.WithContext(ctx)
+
+	blocks, err := batchQueryBlocks(ctx, client, from, to, false)
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to query block headers")
+	}
+
+	fromBlock, toBlock := types.BlockNumber(from), types.BlockNumber(to)
+	logs, err := client.Eth.Logs(types.FilterQuery{
+		FromBlock: &fromBlock,
+		ToBlock:   &toBlock,
+		Addresses: adapter.filter.Addresses,
+		Topics:    adapter.filter.Topics,
+	})
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to get logs by block range")
+	}
+
+	result := make([]LogData, 0, len(blocks))
+	for _, v := range blocks {
+		result = append(result, LogData{v, []types.Log{}})
+	}
+
+	for i, v := range logs {
+		if v.BlockNumber < from || v.BlockNumber > to {
+			return nil, errors.Errorf("Log block number out of range, index = %v, block = %v", i, v.BlockNumber)
+		}
+
+		data := &result[v.BlockNumber-from]
+
+		// detect temp chain reorg
+		if v.BlockHash != data.Block.Hash {
+			return nil, errors.Errorf("Log block hash mismatch, index = %v, block = %v", i, v.BlockNumber)
+		}
+
+		data.Logs = append(data.Logs, v)
+	}
+
+	return result, nil
+}
+
+// GetBlockHash implements the poll.Adapter[T] interface.
+func (adapter *LogAdapter) GetBlockHash(data LogData) string {
+	return data.Block.Hash.Hex()
+}
+
+// GetParentBlockHash implements the poll.Adapter[T] interface.
+func (adapter *LogAdapter) GetParentBlockHash(data LogData) string {
+	return data.Block.ParentHash.Hex()
+}
@@ -0,0 +1,200 @@
+package evm
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/Conflux-Chain/go-conflux-util/blockchain/sync/poll/testutil"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestRPCServer starts a mock evm RPC server, which is closed once test completed.
+func newTestRPCServer(t *testing.T, handler testutil.RPCHandler) *testutil.RPCServer {
+	server := testutil.NewRPCServer(handler)
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func testBlockHash(blockNumber uint64) common.Hash {
+	return common.BigToHash(new(big.Int).SetUint64(blockNumber + 1000))
+}
+
+// newTestRPCBlock returns a block without transaction details in JSON-RPC format.
+func newTestRPCBlock(blockNumber uint64) map[string]any {
+	return map[string]any{
+		"number":       hexUint64(blockNumber),
+		"hash":         testBlockHash(blockNumber),
+		"parentHash":   testBlockHash(blockNumber - 1),
+		"difficulty":   "0x0",
+		"transactions": []common.Hash{},
+	}
+}
+
+func newTestRPCLog(blockNumber uint64, index uint) map[string]any {
+	return map[string]any{
+		"address":     testContract,
+		"blockHash":   testBlockHash(blockNumber),
+		"blockNumber": hexUint64(blockNumber),
+		"logIndex":    hexUint64(uint64(index)),
+		"topics":      []common.Hash{},
+		"data":        "0x",
+	}
+}
+
+func hexUint64(value uint64) string {
+	return "0x" + new(big.Int).SetUint64(value).Text(16)
+}
+
+// parseBlockNumber parses the block number of eth_getBlockByNumber request.
+func parseBlockNumber(params []json.RawMessage) (uint64, error) {
+	var bn types.BlockNumber
+	if err := json.Unmarshal(params[0], &bn); err != nil {
+		return 0, errors.WithMessage(err, "Invalid block number")
+	}
+
+	return uint64(bn.Int64()), nil
+}
+
+func TestLogAdapterGetBlockData(t *testing.T) {
+	topic := common.HexToHash("0x01")
+	filter := LogFilter{
+		Addresses: []common.Address{testContract},
+		Topics:    [][]common.Hash{{topic}},
+	}
+
+	server := newTestRPCServer(t, func(method string, params []json.RawMessage) (any, error) {
+		switch method {
+		case "eth_getBlockByNumber":
+			bn, err := parseBlockNumber(params)
+			if err != nil || bn > 5 {
+				return nil, err
+			}
+
+			return newTestRPCBlock(bn), nil
+		case "eth_getLogs":
+			return []any{newTestRPCLog(5, 0), newTestRPCLog(5, 1)}, nil
+		default:
+			return nil, errors.Errorf("Unsupported method %v", method)
+		}
+	})
+
+	adapter, err := NewLogAdapter(server.URL, filter, AdapterOption{})
+	assert.NoError(t, err)
+	defer adapter.Close()
+
+	data, err := adapter.GetBlockData(context.Background(), 5)
+	assert.NoError(t, err)
+	assert.Equal(t, testBlockHash(5), data.Block.Hash)
+	assert.Equal(t, testBlockHash(5).Hex(), adapter.GetBlockHash(data))
+	assert.Equal(t, testBlockHash(4).Hex(), adapter.GetParentBlockHash(data))
+	assert.Equal(t, 2, len(data.Logs))
+	assert.Equal(t, uint64(5), data.BlockNumber())
+
+	// logs are filtered by block hash along with contract addresses and topics
+	requests := server.Requests("eth_getLogs")
+	assert.Equal(t, 1, len(requests))
+
+	var query types.FilterQuery
+	assert.NoError(t, json.Unmarshal(requests[0].Params[0], &query))
+	assert.Equal(t, testBlockHash(5), *query.BlockHash)
+	assert.Nil(t, query.FromBlock)
+	assert.Nil(t, query.ToBlock)
+	assert.Equal(t, filter.Addresses, query.Addresses)
+	assert.Equal(t, filter.Topics, query.Topics)
+
+	// block not found
+	_, err = adapter.GetBlockData(context.Background(), 6)
+	assert.ErrorContains(t, err, "Block not found by number 6")
+}
+
+func TestLogAdapterGetBlockDataNoLogs(t *testing.T) {
+	server := newTestRPCServer(t, func(method string, params []json.RawMessage) (any, error) {
+		if method == "eth_getLogs" {
+			return nil, nil
+		}
+
+		bn, err := parseBlockNumber(params)
+		if err != nil {
+			return nil, err
+		}
+
+		return newTestRPCBlock(bn), nil
+	})
+
+	adapter, err := NewLogAdapter(server.URL, LogFilter{}, AdapterOption{})
+	assert.NoError(t, err)
+	defer adapter.Close()
+
+	data, err := adapter.GetBlockData(context.Background(), 3)
+	assert.NoError(t, err)
+	assert.NotNil(t, data.Logs)
+	assert.Empty(t, data.Logs)
+}
+
+func TestLogAdapterGetBlockDataRange(t *testing.T) {
+	var logs []any
+	handler := testBlockRPCHandler(10)
+	server := newTestRPCServer(t, func(method string, params []json.RawMessage) (any, error) {
+		if method == "eth_getLogs" {
+			return logs, nil
+		}
+
+		return handler(method, params)
+	})
+
+	filter := LogFilter{Addresses: []common.Address{testContract}}
+	adapter, err := NewLogAdapter(server.URL, filter, AdapterOption{})
+	assert.NoError(t, err)
+	defer adapter.Close()
+
+	// logs are grouped by block
+	logs = []any{newTestRPCLog(2, 0), newTestRPCLog(2, 1), newTestRPCLog(4, 2)}
+	data, err := adapter.GetBlockDataRange(context.Background(), 1, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, len(data))
+
+	for i, v := range data {
+		assert.Equal(t, uint64(1+i), v.BlockNumber())
+		assert.NotNil(t, v.Logs)
+	}
+
+	assert.Equal(t, 0, len(data[0].Logs))
+	assert.Equal(t, []uint{0, 1}, []uint{data[1].Logs[0].Index, data[1].Logs[1].Index})
+	assert.Equal(t, 0, len(data[2].Logs))
+	assert.Equal(t, uint(2), data[3].Logs[0].Index)
+	assert.Equal(t, 0, len(data[4].Logs))
+
+	// logs are filtered by block range along with contract addresses
+	requests := server.Requests("eth_getLogs")
+	assert.Equal(t, 1, len(requests))
+
+	var query types.FilterQuery
+	assert.NoError(t, json.Unmarshal(requests[0].Params[0], &query))
+	assert.Nil(t, query.BlockHash)
+	assert.Equal(t, int64(1), query.FromBlock.Int64())
+	assert.Equal(t, int64(5), query.ToBlock.Int64())
+	assert.Equal(t, filter.Addresses, query.Addresses)
+
+	// chain reorg happened between block headers and logs queried
+	reorgLog := newTestRPCLog(3, 0)
+	reorgLog["blockHash"] = common.HexToHash("0x03")
+	logs = []any{reorgLog}
+	_, err = adapter.GetBlockDataRange(context.Background(), 1, 5)
+	assert.ErrorContains(t, err, "Log block hash mismatch, index = 0, block = 3")
+
+	// log out of range
+	logs = []any{newTestRPCLog(6, 0)}
+	_, err = adapter.GetBlockDataRange(context.Background(), 1, 5)
+	assert.ErrorContains(t, err, "Log block number out of range, index = 0, block = 6")
+
+	// range crosses the latest block
+	logs = nil
+	_, err = adapter.GetBlockDataRange(context.Background(), 8, 12)
+	assert.ErrorContains(t, err, "Block not found by number 11")
+}
@@ -0,0 +1,207 @@
+package evm
+
+import (
+	"encoding/json"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/openweb3/web3go/types"
+)
+
+// Block is the database model of eSpace block.
+type Block struct {
+	Number        uint64 `gorm:"primaryKey;autoIncrement:false"`
+	Hash          string `gorm:"size:66;not null;index"`
+	ParentHash    string `gorm:"size:66;not null"`
+	Timestamp     uint64 `gorm:"not null"`
+	Miner         string `gorm:"size:42;not null"`
+	GasLimit      uint64 `gorm:"not null"`
+	GasUsed       uint64 `gorm:"not null"`
+	BaseFeePerGas string `gorm:"size:78"` // empty if not supported
+	NumTxs        int    `gorm:"not null"`
+}
+
+func (Block) TableName() string {
+	return "evm_blocks"
+}
+
+// Transaction is the database model of eSpace transaction, along with the execution result in receipt if any.
+type Transaction struct {
+	ID          uint64
+	BlockNumber uint64  `gorm:"not null;uniqueIndex:idx_evm_transactions_block_index,priority:1"`
+	Hash        string  `gorm:"size:66;not null;index"`
+	Index       uint64  `gorm:"not null;uniqueIndex:idx_evm_transactions_block_index,priority:2"`
+	From        string  `gorm:"size:42;not null;index"`
+	To          *string `gorm:"size:42;index"` // nil for contract creation
+	Value       string  `gorm:"size:78;not null"`
+	Nonce       uint64  `gorm:"not null"`
+	Gas         uint64  `gorm:"not null"`
+	GasPrice    string  `gorm:"size:78"`
+	Input       []byte
+
+	// nil if receipts ignored
+	Status          *uint64
+	GasUsed         *uint64
+	ContractAddress *string `gorm:"size:42"`
+}
+
+func (Transaction) TableName() string {
+	return "evm_transactions"
+}
+
+// Log is the database model of eSpace event log.
+type Log struct {
+	ID          uint64
+	BlockNumber uint64 `gorm:"not null;uniqueIndex:idx_evm_logs_block_index,priority:1"`
+	TxHash      string `gorm:"size:66;not null;index"`
+	TxIndex     uint   `gorm:"not null"`
+	Index       uint   `gorm:"not null;uniqueIndex:idx_evm_logs_block_index,priority:2"`
+	Address     string `gorm:"size:42;not null;index"`
+	Topic0      string `gorm:"size:66;index"`
+	Topic1      string `gorm:"size:66"`
+	Topic2      string `gorm:"size:66"`
+	Topic3      string `gorm:"size:66"`
+	Data        []byte
+}
+
+func (Log) TableName() string {
+	return "evm_logs"
+}
+
+// Trace is the database model of eSpace trace, in which action and result are persisted in JSON format.
+type Trace struct {
+	ID           uint64
+	BlockNumber  uint64  `gorm:"not null;uniqueIndex:idx_evm_traces_block_tx_address,priority:1"`
+	TxHash       *string `gorm:"size:66;index"`
+	TxPosition   *uint   `gorm:"uniqueIndex:idx_evm_traces_block_tx_address,priority:2"` // nil for block rewards
+	Type         string  `gorm:"size:32;not null"`
+	TraceAddress string  `gorm:"size:256;not null;uniqueIndex:idx_evm_traces_block_tx_address,priority:3"` // in JSON format, e.g. [0,1]
+	Subtraces    uint    `gorm:"not null"`
+	Action       []byte
+	Result       []byte
+	Error        *string
+	Valid        *bool // nil if not supported by fullnode
+}
+
+func (Trace) TableName() string {
+	return "evm_traces"
+}
+
+// Models returns all the database models of eSpace data, e.g. to create tables.
+func Models() []any {
+	return []any{&Block{}, &Transaction{}, &Log{}, &Trace{}}
+}
+
+func newBlock(block *types.Block) Block {
+	return Block{
+		Number:        block.Number.Uint64(),
+		Hash:          block.Hash.Hex(),
+		ParentHash:    block.ParentHash.Hex(),
+		Timestamp:     block.Timestamp,
+		Miner:         block.Miner.Hex(),
+		GasLimit:      block.GasLimit,
+		GasUsed:       block.GasUsed,
+		BaseFeePerGas: bigToString(block.BaseFeePerGas),
+		NumTxs:        len(block.Transactions.Transactions()),
+	}
+}
+
+func newTransaction(blockNumber uint64, tx *types.TransactionDetail, receipt *types.Receipt) Transaction {
+	result := Transaction{
+		BlockNumber: blockNumber,
+		Hash:        tx.Hash.Hex(),
+		From:        tx.From.Hex(),
+		To:          addressToString(tx.To),
+		Value:       bigToString(tx.Value),
+		Nonce:       tx.Nonce,
+		Gas:         tx.Gas,
+		GasPrice:    bigToString(tx.GasPrice),
+		Input:       tx.Input,
+	}
+
+	if tx.TransactionIndex != nil {
+		result.Index = *tx.TransactionIndex
+	}
+
+	if receipt != nil {
+		result.Status = receipt.Status
+		result.GasUsed = &receipt.GasUsed
+		result.ContractAddress = addressToString(receipt.ContractAddress)
+	}
+
+	return result
+}
+
+func newLog(log *types.Log) Log {
+	result := Log{
+		BlockNumber: log.BlockNumber,
+		TxHash:      log.TxHash.Hex(),
+		TxIndex:     log.TxIndex,
+		Index:       log.Index,
+		Address:     log.Address.Hex(),
+		Data:        log.Data,
+	}
+
+	topics := []*string{&result.Topic0, &result.Topic1, &result.Topic2, &result.Topic3}
+	for i, v := range log.Topics {
+		if i < len(topics) {
+			*topics[i] = v.Hex()
+		}
+	}
+
+	return result
+}
+
+func newTrace(trace *types.LocalizedTrace) (Trace, error) {
+	traceAddress, err := json.Marshal(trace.TraceAddress)
+	if err != nil {
+		return Trace{}, err
+	}
+
+	action, err := json.Marshal(trace.Action)
+	if err != nil {
+		return Trace{}, err
+	}
+
+	result := Trace{
+		BlockNumber:  trace.BlockNumber,
+		TxPosition:   trace.TransactionPosition,
+		Type:         string(trace.Type),
+		TraceAddress: string(traceAddress),
+		Subtraces:    trace.Subtraces,
+		Action:       action,
+		Error:        trace.Error,
+		Valid:        trace.Valid,
+	}
+
+	if trace.TransactionHash != nil {
+		hash := trace.TransactionHash.Hex()
+		result.TxHash = &hash
+	}
+
+	if trace.Result != nil {
+		if result.Result, err = json.Marshal(trace.Result); err != nil {
+			return Trace{}, err
+		}
+	}
+
+	return result, nil
+}
+
+func bigToString(value *big.Int) string {
+	if value == nil {
+		return ""
+	}
+
+	return value.String()
+}
+
+func addressToString(addr *common.Address) *string {
+	if addr == nil {
+		return nil
+	}
+
+	hex := addr.Hex()
+
+	return &hex
+}
@@ -0,0 +1,225 @@
+package evm
+
+import (
+	"github.com/Conflux-Chain/go-conflux-util/blockchain/sync/process/db"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+var ModuleName = "sync.evm"
+
+type ProcessorOption struct {
+	// IgnoreTraces indicates not to persist traces.
+	IgnoreTraces bool
+
+	// Addresses is the contract addresses to keep transactions, logs and traces. If empty, all data will be kept.
+	//
+	// Note, transactions are kept if sent to or created any address, or emitted any event log of addresses.
+	Addresses []common.Address
+}
+
+var (
+	_ db.RevertableProcessor[BlockData] = (*Processor)(nil)
+	_ db.BatchProcessor[BlockData]      = (*Processor)(nil)
+	_ db.PipelineBatchProcessor         = (*Processor)(nil)
+	_ db.RangeRevertableProcessor       = (*Processor)(nil)
+)
+
+// Processor persists eSpace blocks, transactions, logs and traces into the pre-defined database models,
+// which implements both db.RevertableProcessor[BlockData] and db.BatchProcessor[BlockData] interfaces.
+type Processor struct {
+	option    ProcessorOption
+	addresses map[string]bool // hex addresses to keep data
+	batch     models
+}
+
+// NewProcessor creates a new processor, and creates the database tables if absent.
+func NewProcessor(db *gorm.DB, option ...ProcessorOption) (*Processor, error) {
+	if err := db.AutoMigrate(Models()...); err != nil {
+		return nil, errors.WithMessage(err, "Failed to create eSpace tables")
+	}
+
+	var opt ProcessorOption
+	if len(option) > 0 {
+		opt = option[0]
+	}
+
+	processor := Processor{option: opt}
+
+	if len(opt.Addresses) > 0 {
+		processor.addresses = make(map[string]bool)
+
+		for _, v := range opt.Addresses {
+			processor.addresses[v.Hex()] = true
+		}
+	}
+
+	return &processor, nil
+}
+
+// Process implements the db.Processor[BlockData] interface.
+func (processor *Processor) Process(data BlockData) db.Operation {
+	var models models
+	if err := processor.add(&models, data); err != nil {
+		return db.OperationFunc(func(tx *gorm.DB) error {
+			return err
+		})
+	}
+
+	return &models
+}
+
+// Revert implements the db.RevertableProcessor[BlockData] interface.
+func (processor *Processor) Revert(data BlockData) db.Operation {
+	blockNumber := data.Block.Number.Uint64()
+
+	return db.ComposeOperation(
+		db.DeleteOperation(&Trace{}, "block_number >= ?", blockNumber),
+		db.DeleteOperation(&Log{}, "block_number >= ?", blockNumber),
+		db.DeleteOperation(&Transaction{}, "block_number >= ?", blockNumber),
+		db.DeleteOperation(&Block{}, "number >= ?", blockNumber),
+	)
+}
+
+// RevertRange implements the db.RangeRevertableProcessor interface.
+func (processor *Processor) RevertRange(fromBlockNumber, toBlockNumber uint64) db.Operation {
+	return db.ComposeOperation(
+		db.DeleteOperation(&Trace{}, "block_number BETWEEN ? AND ?", fromBlockNumber, toBlockNumber),
+		db.DeleteOperation(&Log{}, "block_number BETWEEN ? AND ?", fromBlockNumber, toBlockNumber),
+		db.DeleteOperation(&Transaction{}, "block_number BETWEEN ? AND ?", fromBlockNumber, toBlockNumber),
+		db.DeleteOperation(&Block{}, "number BETWEEN ? AND ?", fromBlockNumber, toBlockNumber),
+	)
+}
+
+// BatchProcess implements the db.BatchProcessor[BlockData] interface.
+func (processor *Processor) BatchProcess(data BlockData) int {
+	if err := processor.add(&processor.batch, data); err != nil && processor.batch.err == nil {
+		processor.batch.err = err
+	}
+
+	return processor.batch.size()
+}
+
+// BatchExec implements the db.BatchProcessor[BlockData] interface.
+func (processor *Processor) BatchExec(tx *gorm.DB, createBatchSize int) error {
+	return processor.batch.exec(tx, createBatchSize)
+}
+
+// BatchReset implements the db.BatchProcessor[BlockData] interface.
+func (processor *Processor) BatchReset() {
+	processor.batch = models{}
+}
+
+// BatchDetach implements the db.PipelineBatchProcessor interface.
+func (processor *Processor) BatchDetach(createBatchSize int) db.Operation {
+	batch := processor.batch
+	processor.batch = models{}
+
+	return db.OperationFunc(func(tx *gorm.DB) error {
+		return batch.exec(tx, createBatchSize)
+	})
+}
+
+// add converts the given block data into database models.
+func (processor *Processor) add(models *models, data BlockData) error {
+	blockNumber := data.Block.Number.Uint64()
+	models.blocks = append(models.blocks, newBlock(data.Block))
+
+	keepTxs := make(map[string]bool)
+
+	for i, v := range data.Block.Transactions.Transactions() {
+		var receipt *types.Receipt
+		if i < len(data.Receipts) {
+			receipt = data.Receipts[i]
+		}
+
+		keep := processor.keep(v.To) || (receipt != nil && processor.keep(receipt.ContractAddress))
+
+		if receipt != nil {
+			for _, eventLog := range receipt.Logs {
+				if processor.keep(&eventLog.Address) {
+					models.logs = append(models.logs, newLog(eventLog))
+					keep = true
+				}
+			}
+		}
+
+		if keep {
+			models.txs = append(models.txs, newTransaction(blockNumber, &v, receipt))
+			keepTxs[v.Hash.Hex()] = true
+		}
+	}
+
+	if processor.option.IgnoreTraces {
+		return nil
+	}
+
+	for _, v := range data.Traces {
+		if processor.addresses != nil && (v.TransactionHash == nil || !keepTxs[v.TransactionHash.Hex()]) {
+			continue
+		}
+
+		trace, err := newTrace(&v)
+		if err != nil {
+			return errors.WithMessagef(err, "Failed to convert trace of block %v", blockNumber)
+		}
+
+		models.traces = append(models.traces, trace)
+	}
+
+	return nil
+}
+
+// keep returns whether to keep data of the given address.
+func (processor *Processor) keep(addr *common.Address) bool {
+	if processor.addresses == nil {
+		return true
+	}
+
+	return addr != nil && processor.addresses[addr.Hex()]
+}
+
+// models is the database models to create, which implements the db.Operation interface.
+type models struct {
+	blocks []Block
+	txs    []Transaction
+	logs   []Log
+	traces []Trace
+	err    error // failed to convert any data into models
+}
+
+func (m *models) size() int {
+	return len(m.blocks) + len(m.txs) + len(m.logs) + len(m.traces)
+}
+
+// Exec implements the db.Operation interface.
+func (m *models) Exec(tx *gorm.DB) error {
+	return m.exec(tx, 0)
+}
+
+// exec creates all models in batch, and creates all models of a table at a time if createBatchSize is 0.
+func (m *models) exec(tx *gorm.DB, createBatchSize int) error {
+	if m.err != nil {
+		return m.err
+	}
+
+	if err := db.CreateInBatches(tx, m.blocks, createBatchSize); err != nil {
+		return errors.WithMessage(err, "Failed to create blocks")
+	}
+
+	if err := db.CreateInBatches(tx, m.txs, createBatchSize); err != nil {
+		return errors.WithMessage(err, "Failed to create transactions")
+	}
+
+	if err := db.CreateInBatches(tx, m.logs, createBatchSize); err != nil {
+		return errors.WithMessage(err, "Failed to create logs")
+	}
+
+	if err := db.CreateInBatches(tx, m.traces, createBatchSize); err != nil {
+		return errors.WithMessage(err, "Failed to create traces")
+	}
+
+	return nil
+}
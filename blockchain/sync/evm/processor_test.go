@@ -0,0 +1,206 @@
+package evm
+
+import (
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/Conflux-Chain/go-conflux-util/store"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/openweb3/web3go/types"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+var (
+	testContract = common.HexToAddress("0x0000000000000000000000000000000000000001")
+	testAccount  = common.HexToAddress("0x0000000000000000000000000000000000000002")
+)
+
+func newTestBlockData(blockNumber uint64) BlockData {
+	txIndexes := []uint64{0, 1}
+	txs := []types.TransactionDetail{
+		{Hash: common.BigToHash(big.NewInt(int64(blockNumber*10 + 1))), TransactionIndex: &txIndexes[0], To: &testContract, Value: big.NewInt(0)},
+		{Hash: common.BigToHash(big.NewInt(int64(blockNumber*10 + 2))), TransactionIndex: &txIndexes[1], To: &testAccount, Value: big.NewInt(1)},
+	}
+
+	status := uint64(1)
+
+	return BlockData{
+		Block: &types.Block{
+			Number:       new(big.Int).SetUint64(blockNumber),
+			Hash:         common.BigToHash(new(big.Int).SetUint64(blockNumber)),
+			Transactions: *types.NewTxOrHashListByTxs(txs),
+		},
+		Receipts: []*types.Receipt{
+			{Status: &status, Logs: []*types.Log{{Address: testContract, BlockNumber: blockNumber, TxHash: txs[0].Hash}}},
+			{Status: &status, Logs: []*types.Log{}},
+		},
+		Traces: []types.LocalizedTrace{
+			{Type: types.TRACE_CALL, BlockNumber: blockNumber, TransactionHash: &txs[0].Hash},
+			{Type: types.TRACE_CALL, BlockNumber: blockNumber, TransactionHash: &txs[1].Hash},
+		},
+	}
+}
+
+func mustCount(t *testing.T, db *gorm.DB, model any) int64 {
+	var count int64
+	assert.NoError(t, db.Model(model).Count(&count).Error)
+	return count
+}
+
+func TestProcessor(t *testing.T) {
+	storeConfig := store.NewMemoryConfig()
+	storeConfig.Sqlite.Path = filepath.Join(t.TempDir(), "evm.db")
+	DB := storeConfig.MustOpenOrCreate()
+
+	processor, err := NewProcessor(DB)
+	assert.NoError(t, err)
+
+	// catch up in batch
+	for i := uint64(1); i <= 3; i++ {
+		processor.BatchProcess(newTestBlockData(i))
+	}
+	assert.NoError(t, processor.BatchExec(DB, 2))
+	processor.BatchReset()
+
+	// latest
+	assert.NoError(t, processor.Process(newTestBlockData(4)).Exec(DB))
+
+	assert.Equal(t, int64(4), mustCount(t, DB, &Block{}))
+	assert.Equal(t, int64(8), mustCount(t, DB, &Transaction{}))
+	assert.Equal(t, int64(4), mustCount(t, DB, &Log{}))
+	assert.Equal(t, int64(8), mustCount(t, DB, &Trace{}))
+
+	// revert blocks 3 and 4
+	assert.NoError(t, processor.Revert(newTestBlockData(3)).Exec(DB))
+
+	assert.Equal(t, int64(2), mustCount(t, DB, &Block{}))
+	assert.Equal(t, int64(4), mustCount(t, DB, &Transaction{}))
+	assert.Equal(t, int64(2), mustCount(t, DB, &Log{}))
+	assert.Equal(t, int64(4), mustCount(t, DB, &Trace{}))
+}
+
+func TestProcessorBatchDetach(t *testing.T) {
+	storeConfig := store.NewMemoryConfig()
+	storeConfig.Sqlite.Path = filepath.Join(t.TempDir(), "evm.db")
+	DB := storeConfig.MustOpenOrCreate()
+
+	processor, err := NewProcessor(DB)
+	assert.NoError(t, err)
+
+	for i := uint64(1); i <= 3; i++ {
+		processor.BatchProcess(newTestBlockData(i))
+	}
+	op := processor.BatchDetach(2)
+
+	// build the next batch while writing the detached one
+	processor.BatchProcess(newTestBlockData(4))
+	assert.NoError(t, op.Exec(DB))
+	assert.Equal(t, int64(3), mustCount(t, DB, &Block{}))
+
+	assert.NoError(t, processor.BatchDetach(2).Exec(DB))
+	assert.Equal(t, int64(4), mustCount(t, DB, &Block{}))
+	assert.Equal(t, int64(8), mustCount(t, DB, &Transaction{}))
+}
+
+func TestProcessorAddresses(t *testing.T) {
+	storeConfig := store.NewMemoryConfig()
+	storeConfig.Sqlite.Path = filepath.Join(t.TempDir(), "evm.db")
+	DB := storeConfig.MustOpenOrCreate()
+
+	processor, err := NewProcessor(DB, ProcessorOption{
+		IgnoreTraces: true,
+		Addresses:    []common.Address{testContract},
+	})
+	assert.NoError(t, err)
+
+	assert.NoError(t, processor.Process(newTestBlockData(1)).Exec(DB))
+
+	var txs []Transaction
+	assert.NoError(t, DB.Find(&txs).Error)
+	assert.Equal(t, 1, len(txs))
+	assert.Equal(t, testContract.Hex(), *txs[0].To)
+
+	assert.Equal(t, int64(1), mustCount(t, DB, &Block{}))
+	assert.Equal(t, int64(1), mustCount(t, DB, &Log{}))
+	assert.Equal(t, int64(0), mustCount(t, DB, &Trace{}))
+}
+
+func TestProcessorDuplicated(t *testing.T) {
+	storeConfig := store.NewMemoryConfig()
+	storeConfig.Sqlite.Path = filepath.Join(t.TempDir(), "evm.db")
+	DB := storeConfig.MustOpenOrCreate()
+
+	processor, err := NewProcessor(DB)
+	assert.NoError(t, err)
+
+	assert.NoError(t, processor.Process(newTestBlockData(1)).Exec(DB))
+
+	// logs of block 1 again
+	data := newTestBlockData(1)
+	data.Block.Number = big.NewInt(2)
+	data.Traces = nil
+	assert.ErrorContains(t, processor.Process(data).Exec(DB), "Failed to create logs")
+
+	// transactions of the same index
+	data = newTestBlockData(3)
+	data.Block.Transactions.Transactions()[1].TransactionIndex = data.Block.Transactions.Transactions()[0].TransactionIndex
+	assert.ErrorContains(t, processor.Process(data).Exec(DB), "Failed to create transactions")
+
+	// traces of the same transaction and trace address
+	data = newTestBlockData(4)
+	txPosition := uint(0)
+	data.Traces[0].TransactionPosition = &txPosition
+	data.Traces[1].TransactionPosition = &txPosition
+	assert.ErrorContains(t, processor.Process(data).Exec(DB), "Failed to create traces")
+}
+
+func TestProcessorInvalidTrace(t *testing.T) {
+	storeConfig := store.NewMemoryConfig()
+	storeConfig.Sqlite.Path = filepath.Join(t.TempDir(), "evm.db")
+	DB := storeConfig.MustOpenOrCreate()
+
+	processor, err := NewProcessor(DB)
+	assert.NoError(t, err)
+
+	data := newTestBlockData(2)
+	data.Traces[1].Action = make(chan int)
+
+	err = processor.Process(data).Exec(DB)
+	assert.ErrorContains(t, err, "Failed to convert trace of block 2")
+	assert.Equal(t, int64(0), mustCount(t, DB, &Block{}))
+
+	// failed to execute batch
+	processor.BatchProcess(newTestBlockData(1))
+	processor.BatchProcess(data)
+	processor.BatchProcess(newTestBlockData(3))
+	assert.ErrorContains(t, processor.BatchExec(DB, 2), "Failed to convert trace of block 2")
+	assert.Equal(t, int64(0), mustCount(t, DB, &Block{}))
+
+	// succeeded after reset
+	processor.BatchReset()
+	processor.BatchProcess(newTestBlockData(1))
+	assert.NoError(t, processor.BatchExec(DB, 2))
+	assert.Equal(t, int64(1), mustCount(t, DB, &Block{}))
+}
+
+func TestProcessorTraceValid(t *testing.T) {
+	storeConfig := store.NewMemoryConfig()
+	storeConfig.Sqlite.Path = filepath.Join(t.TempDir(), "evm.db")
+	DB := storeConfig.MustOpenOrCreate()
+
+	processor, err := NewProcessor(DB)
+	assert.NoError(t, err)
+
+	data := newTestBlockData(1)
+	valid := false
+	data.Traces[1].Valid = &valid
+	assert.NoError(t, processor.Process(data).Exec(DB))
+
+	var traces []Trace
+	assert.NoError(t, DB.Order("id ASC").Find(&traces).Error)
+	assert.Equal(t, 2, len(traces))
+	assert.Nil(t, traces[0].Valid)
+	assert.Equal(t, &valid, traces[1].Valid)
+}
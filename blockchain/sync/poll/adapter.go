@@ -1,6 +1,10 @@
 package poll
 
-import "context"
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
 
 // Adapter adapts any data source to fetch blockchain data. Typically, a PRC adapter is used to
 // fetch blockchain data from fullnode.
@@ -28,3 +32,46 @@ type Adapter[T any] interface {
 	// GetParentBlockHash returns the parent block hash of given blockchain data.
 	GetParentBlockHash(data T) string
 }
+
+// Subscriber is optionally implemented by adapters to notify new blocks in push mode, e.g. via websocket,
+// so that poller could poll the latest blockchain data immediately once new block arrived.
+type Subscriber interface {
+
+	// SubscribeNewBlocks subscribes new blocks, and returns a channel to receive notifications.
+	//
+	// Note, the returned channel should be closed once the subscription dropped or context done.
+	SubscribeNewBlocks(ctx context.Context) (<-chan struct{}, error)
+}
+
+// RangeAdapter is optionally implemented by adapters to poll blockchain data in batch, e.g. via JSON-RPC batch
+// requests, so as to reduce the RPC round-trips in catch up phase.
+type RangeAdapter[T any] interface {
+
+	// GetBlockDataRange returns the whole blockchain data in range [from, to] in sequence.
+	GetBlockDataRange(ctx context.Context, from, to uint64) ([]T, error)
+}
+
+// getBlockDataRange returns the blockchain data in range [from, to] in batch if adapter implements the
+// RangeAdapter[T] interface. Otherwise, it polls blockchain data block by block.
+func getBlockDataRange[T any](ctx context.Context, adapter Adapter[T], from, to uint64) ([]T, error) {
+	if rangeAdapter, ok := adapter.(RangeAdapter[T]); ok {
+		return rangeAdapter.GetBlockDataRange(ctx, from, to)
+	}
+
+	if from > to {
+		return nil, errors.Errorf("Invalid block range [%v, %v]", from, to)
+	}
+
+	result := make([]T, 0, to-from+1)
+
+	for bn := from; bn <= to; bn++ {
+		data, err := adapter.GetBlockData(ctx, bn)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, data)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,279 @@
+package poll
+
+import (
+	"context"
+	"encoding/json"
+	"sync/atomic"
+
+	"github.com/Conflux-Chain/go-conflux-util/log"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CachedBlock is the database model to cache the finalized blockchain data.
+//
+// Note, multiple caches could share the same database table with different names, e.g. eSpace and core space.
+type CachedBlock struct {
+	Name   string `gorm:"primaryKey;size:64"`
+	Number uint64 `gorm:"primaryKey;autoIncrement:false"`
+	Hash   string `gorm:"size:128;not null"`
+	Data   []byte `gorm:"not null"`
+}
+
+func (CachedBlock) TableName() string {
+	return "sync_cached_blocks"
+}
+
+// Codec is implemented by types that encode and decode blockchain data to cache.
+type Codec[T any] interface {
+	Encode(data T) ([]byte, error)
+	Decode(encoded []byte) (T, error)
+}
+
+// JSONCodec implements the Codec[T] interface in JSON format.
+type JSONCodec[T any] struct{}
+
+// Encode implements the Codec[T] interface.
+func (JSONCodec[T]) Encode(data T) ([]byte, error) {
+	return json.Marshal(data)
+}
+
+// Decode implements the Codec[T] interface.
+func (JSONCodec[T]) Decode(encoded []byte) (data T, err error) {
+	err = json.Unmarshal(encoded, &data)
+	return
+}
+
+type CacheAdapterOption struct {
+	// Name is used to distinguish caches of different chains or data types in the same database table.
+	Name string `default:"default"`
+
+	// Offline indicates to poll data from cache only without any RPC, e.g. re-run processors from cache.
+	//
+	// In this case, the finalized and latest block numbers are the highest cached block number without any gap
+	// below it, and the underlying adapter is only used to get block hash and parent block hash of data.
+	Offline bool
+}
+
+var _ Adapter[any] = (*CacheAdapter[any])(nil)
+var _ Subscriber = (*CacheAdapter[any])(nil)
+var _ RangeAdapter[any] = (*CacheAdapter[any])(nil)
+
+// CacheAdapter implements the Adapter[T] interface to cache the finalized blockchain data in database,
+// so as to avoid downloading the same blockchain data again, e.g. re-index data after schema changed.
+//
+// Note, blockchain data that not finalized yet will never be cached.
+//
+// Besides, it implements the Subscriber and RangeAdapter[T] interfaces if the underlying adapter implements them.
+type CacheAdapter[T any] struct {
+	option    CacheAdapterOption
+	inner     Adapter[T]
+	db        *gorm.DB
+	codec     Codec[T]
+	finalized atomic.Int64 // the latest known finalized block number, -1 indicates unknown
+}
+
+// NewCacheAdapter creates an adapter to cache the finalized blockchain data in the given database, and creates
+// the database table if absent. By default, JSONCodec is used to encode and decode data.
+func NewCacheAdapter[T any](inner Adapter[T], db *gorm.DB, codec Codec[T], option ...CacheAdapterOption) (*CacheAdapter[T], error) {
+	if err := db.AutoMigrate(&CachedBlock{}); err != nil {
+		return nil, errors.WithMessage(err, "Failed to create cache table")
+	}
+
+	if codec == nil {
+		codec = JSONCodec[T]{}
+	}
+
+	adapter := CacheAdapter[T]{
+		option: normalizeOpt(option...),
+		inner:  inner,
+		db:     db,
+		codec:  codec,
+	}
+
+	adapter.finalized.Store(-1)
+
+	return &adapter, nil
+}
+
+// GetFinalizedBlockNumber implements the Adapter[T] interface.
+func (adapter *CacheAdapter[T]) GetFinalizedBlockNumber(ctx context.Context) (uint64, error) {
+	if adapter.option.Offline {
+		return adapter.continuousCachedBlockNumber(ctx)
+	}
+
+	finalized, err := adapter.inner.GetFinalizedBlockNumber(ctx)
+	if err == nil {
+		adapter.finalized.Store(int64(finalized))
+	}
+
+	return finalized, err
+}
+
+// GetLatestBlockNumber implements the Adapter[T] interface.
+func (adapter *CacheAdapter[T]) GetLatestBlockNumber(ctx context.Context) (uint64, error) {
+	if adapter.option.Offline {
+		return adapter.continuousCachedBlockNumber(ctx)
+	}
+
+	return adapter.inner.GetLatestBlockNumber(ctx)
+}
+
+// continuousCachedBlockNumber returns the highest cached block number, in which all blocks from the lowest cached
+// block number are cached without any gap.
+func (adapter *CacheAdapter[T]) continuousCachedBlockNumber(ctx context.Context) (uint64, error) {
+	var number *uint64
+
+	// the lowest cached block that the next block is not cached
+	err := adapter.db.WithContext(ctx).Raw(
+		"SELECT MIN(c.number) FROM sync_cached_blocks AS c WHERE c.name = ? AND NOT EXISTS "+
+			"(SELECT 1 FROM sync_cached_blocks AS n WHERE n.name = c.name AND n.number = c.number + 1)",
+		adapter.option.Name,
+	).Scan(&number).Error
+	if err != nil {
+		return 0, errors.WithMessage(err, "Failed to get continuous block number in cache")
+	}
+
+	if number == nil {
+		return 0, errors.New("No data cached")
+	}
+
+	return *number, nil
+}
+
+// GetBlockData implements the Adapter[T] interface.
+func (adapter *CacheAdapter[T]) GetBlockData(ctx context.Context, blockNumber uint64) (data T, err error) {
+	lastFinalized := adapter.finalized.Load()
+	finalized := lastFinalized >= 0 && blockNumber <= uint64(lastFinalized)
+
+	if finalized || adapter.option.Offline {
+		var cached CachedBlock
+
+		err = adapter.db.WithContext(ctx).Where("name = ? AND number = ?", adapter.option.Name, blockNumber).Take(&cached).Error
+		if err == nil {
+			return adapter.codec.Decode(cached.Data)
+		}
+
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return data, errors.WithMessage(err, "Failed to get data from cache")
+		}
+
+		if adapter.option.Offline {
+			return data, errors.Errorf("Block %v not found in cache", blockNumber)
+		}
+	}
+
+	if data, err = adapter.inner.GetBlockData(ctx, blockNumber); err != nil || !finalized {
+		return data, err
+	}
+
+	// cache the finalized data
+	if err := adapter.put(ctx, blockNumber, data); err != nil {
+		log.WithModule(ModuleName).WithError(err).WithField("block", blockNumber).Warn("Failed to cache finalized data")
+	}
+
+	return data, nil
+}
+
+// GetBlockDataRange implements the RangeAdapter[T] interface, which returns data from cache if all blocks in range
+// are cached, and otherwise polls data from the underlying adapter in batch if supported.
+func (adapter *CacheAdapter[T]) GetBlockDataRange(ctx context.Context, from, to uint64) ([]T, error) {
+	if from > to {
+		return nil, errors.Errorf("Invalid block range [%v, %v]", from, to)
+	}
+
+	lastFinalized := adapter.finalized.Load()
+	finalized := lastFinalized >= 0 && to <= uint64(lastFinalized)
+
+	if finalized || adapter.option.Offline {
+		var cached []CachedBlock
+
+		err := adapter.db.WithContext(ctx).
+			Where("name = ? AND number BETWEEN ? AND ?", adapter.option.Name, from, to).
+			Order("number").
+			Find(&cached).Error
+		if err != nil {
+			return nil, errors.WithMessage(err, "Failed to get data from cache")
+		}
+
+		if uint64(len(cached)) == to-from+1 {
+			result := make([]T, 0, len(cached))
+
+			for _, v := range cached {
+				data, err := adapter.codec.Decode(v.Data)
+				if err != nil {
+					return nil, errors.WithMessagef(err, "Failed to decode data of block %v", v.Number)
+				}
+
+				result = append(result, data)
+			}
+
+			return result, nil
+		}
+
+		if adapter.option.Offline {
+			return nil, errors.Errorf("Blocks [%v, %v] not found in cache", from, to)
+		}
+	}
+
+	result, err := getBlockDataRange(ctx, adapter.inner, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	// cache the finalized data
+	for i, v := range result {
+		blockNumber := from + uint64(i)
+		if lastFinalized < 0 || blockNumber > uint64(lastFinalized) {
+			break
+		}
+
+		if err := adapter.put(ctx, blockNumber, v); err != nil {
+			log.WithModule(ModuleName).WithError(err).WithField("block", blockNumber).Warn("Failed to cache finalized data")
+			break
+		}
+	}
+
+	return result, nil
+}
+
+// SubscribeNewBlocks implements the Subscriber interface, which subscribes new blocks from the underlying adapter.
+//
+// Note, subscription is not supported in offline mode.
+func (adapter *CacheAdapter[T]) SubscribeNewBlocks(ctx context.Context) (<-chan struct{}, error) {
+	if adapter.option.Offline {
+		return nil, errors.New("Subscription not supported in offline mode")
+	}
+
+	subscriber, ok := adapter.inner.(Subscriber)
+	if !ok {
+		return nil, errors.New("Subscription not supported by the underlying adapter")
+	}
+
+	return subscriber.SubscribeNewBlocks(ctx)
+}
+
+func (adapter *CacheAdapter[T]) put(ctx context.Context, blockNumber uint64, data T) error {
+	encoded, err := adapter.codec.Encode(data)
+	if err != nil {
+		return errors.WithMessage(err, "Failed to encode data")
+	}
+
+	return adapter.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&CachedBlock{
+		Name:   adapter.option.Name,
+		Number: blockNumber,
+		Hash:   adapter.inner.GetBlockHash(data),
+		Data:   encoded,
+	}).Error
+}
+
+// GetBlockHash implements the Adapter[T] interface.
+func (adapter *CacheAdapter[T]) GetBlockHash(data T) string {
+	return adapter.inner.GetBlockHash(data)
+}
+
+// GetParentBlockHash implements the Adapter[T] interface.
+func (adapter *CacheAdapter[T]) GetParentBlockHash(data T) string {
+	return adapter.inner.GetParentBlockHash(data)
+}
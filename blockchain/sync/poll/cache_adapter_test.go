@@ -0,0 +1,221 @@
+package poll
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/Conflux-Chain/go-conflux-util/blockchain/sync/poll/testutil"
+	"github.com/Conflux-Chain/go-conflux-util/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheAdapter(t *testing.T) {
+	storeConfig := store.NewMemoryConfig()
+	storeConfig.Sqlite.Path = filepath.Join(t.TempDir(), "cache.db")
+	DB := storeConfig.MustOpenOrCreate()
+
+	inner := &multiTestAdapter{name: "a", finalized: 5, latest: 8}
+
+	adapter, err := NewCacheAdapter[testutil.Data](inner, DB, nil)
+	assert.NoError(t, err)
+
+	// finalized block number unknown yet
+	_, err = adapter.GetBlockData(context.Background(), 3)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, inner.requests)
+
+	finalized, err := adapter.GetFinalizedBlockNumber(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(5), finalized)
+
+	// cache finalized blocks only
+	for i := uint64(0); i <= 8; i++ {
+		data, err := adapter.GetBlockData(context.Background(), i)
+		assert.NoError(t, err)
+		assert.Equal(t, testutil.Data{Number: i, Hash: fmt.Sprintf("a-%v", i)}, data)
+	}
+	assert.Equal(t, 10, inner.requests)
+
+	// cache hit for finalized blocks without RPC
+	for i := uint64(0); i <= 5; i++ {
+		_, err := adapter.GetBlockData(context.Background(), i)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 10, inner.requests)
+
+	// re-run from cache only
+	inner.failed = true
+	offline, err := NewCacheAdapter[testutil.Data](inner, DB, nil, CacheAdapterOption{Offline: true})
+	assert.NoError(t, err)
+
+	finalized, err = offline.GetFinalizedBlockNumber(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(5), finalized)
+
+	data, err := offline.GetBlockData(context.Background(), 5)
+	assert.NoError(t, err)
+	assert.Equal(t, testutil.Data{Number: 5, Hash: "a-5"}, data)
+
+	_, err = offline.GetBlockData(context.Background(), 6)
+	assert.Error(t, err)
+	assert.Equal(t, 10, inner.requests)
+}
+
+func TestCacheAdapterOfflineGap(t *testing.T) {
+	storeConfig := store.NewMemoryConfig()
+	storeConfig.Sqlite.Path = filepath.Join(t.TempDir(), "cache.db")
+	DB := storeConfig.MustOpenOrCreate()
+
+	inner := &multiTestAdapter{name: "a", failed: true}
+
+	offline, err := NewCacheAdapter[testutil.Data](inner, DB, nil, CacheAdapterOption{Offline: true})
+	assert.NoError(t, err)
+
+	// nothing cached
+	_, err = offline.GetFinalizedBlockNumber(context.Background())
+	assert.ErrorContains(t, err, "No data cached")
+
+	// block 3 is missed
+	for _, bn := range []uint64{1, 2, 4, 5} {
+		assert.NoError(t, offline.put(context.Background(), bn, testutil.Data{Number: bn, Hash: fmt.Sprintf("a-%v", bn)}))
+	}
+
+	finalized, err := offline.GetFinalizedBlockNumber(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), finalized)
+
+	latest, err := offline.GetLatestBlockNumber(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), latest)
+
+	// gap filled
+	assert.NoError(t, offline.put(context.Background(), 3, testutil.Data{Number: 3, Hash: "a-3"}))
+
+	finalized, err = offline.GetFinalizedBlockNumber(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(5), finalized)
+}
+
+func TestCacheAdapterName(t *testing.T) {
+	storeConfig := store.NewMemoryConfig()
+	storeConfig.Sqlite.Path = filepath.Join(t.TempDir(), "cache.db")
+	DB := storeConfig.MustOpenOrCreate()
+
+	a, err := NewCacheAdapter[testutil.Data](&multiTestAdapter{name: "a"}, DB, nil, CacheAdapterOption{Name: "a", Offline: true})
+	assert.NoError(t, err)
+
+	b, err := NewCacheAdapter[testutil.Data](&multiTestAdapter{name: "b"}, DB, nil, CacheAdapterOption{Name: "b", Offline: true})
+	assert.NoError(t, err)
+
+	// the same block number cached for different names
+	for i := uint64(0); i <= 3; i++ {
+		assert.NoError(t, a.put(context.Background(), i, testutil.Data{Number: i, Hash: fmt.Sprintf("a-%v", i)}))
+	}
+
+	for i := uint64(0); i <= 5; i++ {
+		assert.NoError(t, b.put(context.Background(), i, testutil.Data{Number: i, Hash: fmt.Sprintf("b-%v", i)}))
+	}
+
+	finalized, err := a.GetFinalizedBlockNumber(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), finalized)
+
+	finalized, err = b.GetFinalizedBlockNumber(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(5), finalized)
+
+	data, err := a.GetBlockData(context.Background(), 2)
+	assert.NoError(t, err)
+	assert.Equal(t, testutil.Data{Number: 2, Hash: "a-2"}, data)
+
+	data, err = b.GetBlockData(context.Background(), 2)
+	assert.NoError(t, err)
+	assert.Equal(t, testutil.Data{Number: 2, Hash: "b-2"}, data)
+
+	_, err = a.GetBlockData(context.Background(), 4)
+	assert.ErrorContains(t, err, "Block 4 not found in cache")
+}
+
+func TestCacheAdapterGetBlockDataRange(t *testing.T) {
+	storeConfig := store.NewMemoryConfig()
+	storeConfig.Sqlite.Path = filepath.Join(t.TempDir(), "cache.db")
+	DB := storeConfig.MustOpenOrCreate()
+
+	inner := &multiTestRangeAdapter{multiTestAdapter: &multiTestAdapter{name: "a", finalized: 5, latest: 8}}
+
+	adapter, err := NewCacheAdapter[testutil.Data](inner, DB, nil)
+	assert.NoError(t, err)
+
+	_, err = adapter.GetFinalizedBlockNumber(context.Background())
+	assert.NoError(t, err)
+
+	// cache finalized blocks only
+	data, err := adapter.GetBlockDataRange(context.Background(), 3, 7)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, len(data))
+	assert.Equal(t, 1, inner.rangeRequests)
+
+	for i, v := range data {
+		assert.Equal(t, testutil.Data{Number: uint64(3 + i), Hash: fmt.Sprintf("a-%v", 3+i)}, v)
+	}
+
+	// partially cached
+	_, err = adapter.GetBlockDataRange(context.Background(), 2, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, inner.rangeRequests)
+
+	// cache hit for finalized blocks without RPC
+	data, err = adapter.GetBlockDataRange(context.Background(), 2, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, inner.rangeRequests)
+	assert.Equal(t, testutil.Data{Number: 2, Hash: "a-2"}, data[0])
+	assert.Equal(t, testutil.Data{Number: 5, Hash: "a-5"}, data[3])
+
+	// re-run from cache only
+	inner.failed = true
+	offline, err := NewCacheAdapter[testutil.Data](inner, DB, nil, CacheAdapterOption{Offline: true})
+	assert.NoError(t, err)
+
+	data, err = offline.GetBlockDataRange(context.Background(), 2, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, len(data))
+
+	_, err = offline.GetBlockDataRange(context.Background(), 4, 6)
+	assert.ErrorContains(t, err, "Blocks [4, 6] not found in cache")
+	assert.Equal(t, 2, inner.rangeRequests)
+
+	// invalid range
+	_, err = adapter.GetBlockDataRange(context.Background(), 5, 4)
+	assert.ErrorContains(t, err, "Invalid block range")
+}
+
+func TestCacheAdapterSubscribeNewBlocks(t *testing.T) {
+	storeConfig := store.NewMemoryConfig()
+	storeConfig.Sqlite.Path = filepath.Join(t.TempDir(), "cache.db")
+	DB := storeConfig.MustOpenOrCreate()
+
+	inner := &multiTestSubscriber{&multiTestAdapter{name: "a"}, make(chan struct{})}
+
+	adapter, err := NewCacheAdapter[testutil.Data](inner, DB, nil)
+	assert.NoError(t, err)
+
+	notifyCh, err := adapter.SubscribeNewBlocks(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, (<-chan struct{})(inner.ch), notifyCh)
+
+	// not supported in offline mode
+	offline, err := NewCacheAdapter[testutil.Data](inner, DB, nil, CacheAdapterOption{Offline: true})
+	assert.NoError(t, err)
+
+	_, err = offline.SubscribeNewBlocks(context.Background())
+	assert.ErrorContains(t, err, "Subscription not supported in offline mode")
+
+	// not supported by the underlying adapter
+	adapter, err = NewCacheAdapter[testutil.Data](&multiTestAdapter{name: "a"}, DB, nil)
+	assert.NoError(t, err)
+
+	_, err = adapter.SubscribeNewBlocks(context.Background())
+	assert.ErrorContains(t, err, "Subscription not supported by the underlying adapter")
+}
@@ -2,6 +2,7 @@ package poll
 
 import (
 	"context"
+	"math"
 	"sync"
 	"time"
 
@@ -9,6 +10,7 @@ import (
 	"github.com/Conflux-Chain/go-conflux-util/ctxutil"
 	"github.com/Conflux-Chain/go-conflux-util/health"
 	"github.com/Conflux-Chain/go-conflux-util/log"
+	"github.com/Conflux-Chain/go-conflux-util/metrics"
 	"github.com/Conflux-Chain/go-conflux-util/parallel"
 	"github.com/mcuadros/go-defaults"
 	"github.com/pkg/errors"
@@ -20,6 +22,10 @@ var ModuleName = "sync.poll"
 type CatchUpOption struct {
 	Parallel ParallelOption
 
+	// RangeSize is the number of blocks to poll in batch for each parallel task, if adapter implements
+	// the RangeAdapter[T] interface. Set 1 to poll blockchain data block by block.
+	RangeSize uint64 `default:"10"`
+
 	Buffer struct {
 		Capacity int `default:"1024"`
 		MaxBytes int `default:"256000000"` // 256M
@@ -31,8 +37,11 @@ type CatchUpPoller[T channel.Sizable] struct {
 	option          CatchUpOption
 	adapter         Adapter[T]
 	nextBlockNumber uint64
+	endBlockNumber  uint64                           // the last block number to poll, if less than finalized
 	dataCh          *channel.MemoryBoundedChannel[T] // must bounds the memory to avoid OOM
 	health          *health.TimedCounter
+	limiter         *parallel.AdaptiveLimiter // shared by parallel workers, nil if adaptive concurrency disabled
+	metrics         *pollerMetrics            // shared by parallel workers
 }
 
 func normalizeOpt[T any](option ...T) T {
@@ -48,15 +57,42 @@ func normalizeOpt[T any](option ...T) T {
 }
 
 func NewCatchUpPoller[T channel.Sizable](adapter Adapter[T], nextBlockNumber uint64, option ...CatchUpOption) *CatchUpPoller[T] {
+	return NewBoundedCatchUpPoller(adapter, nextBlockNumber, math.MaxUint64, option...)
+}
+
+// NewBoundedCatchUpPoller creates a catch up poller to poll blockchain data until the given end block number
+// (inclusive) or the latest finalized block, whichever is smaller.
+func NewBoundedCatchUpPoller[T channel.Sizable](
+	adapter Adapter[T], nextBlockNumber, endBlockNumber uint64, option ...CatchUpOption,
+) *CatchUpPoller[T] {
 	opt := normalizeOpt(option...)
 
-	return &CatchUpPoller[T]{
+	poller := CatchUpPoller[T]{
 		option:          opt,
 		adapter:         adapter,
 		nextBlockNumber: nextBlockNumber,
+		endBlockNumber:  endBlockNumber,
 		dataCh:          channel.NewMemoryBoundedChannel[T](opt.Buffer.Capacity, opt.Buffer.MaxBytes),
 		health:          health.NewTimedCounter(opt.Parallel.Health),
+		limiter:         newAdaptiveLimiter(opt.Parallel),
+		metrics:         newPollerMetrics(opt.Parallel.Metrics, "catchup"),
+	}
+
+	if poller.limiter != nil {
+		metrics.GetOrRegisterGauge("%v", opt.Parallel.Metrics.Name("poll/catchup/concurrency")).Update(int64(poller.limiter.Limit()))
 	}
+
+	// report the occupancy of buffer, which is full if data not processed in time
+	metrics.RegisterFunctionalGauge(func() int64 {
+		return int64(poller.dataCh.Len())
+	}, "%v", opt.Parallel.Metrics.Name("poll/catchup/buffer/len"))
+	metrics.RegisterFunctionalGauge(func() int64 {
+		return int64(poller.dataCh.Bytes())
+	}, "%v", opt.Parallel.Metrics.Name("poll/catchup/buffer/bytes"))
+
+	poller.metrics.onNext(nextBlockNumber)
+
+	return &poller
 }
 
 // DataCh returns a read-only channel to consume data.
@@ -114,6 +150,10 @@ func (poller *CatchUpPoller[T]) pollOnce(ctx context.Context) (int, error) {
 		return 0, errors.WithMessage(err, "Failed to get finalized block number")
 	}
 
+	poller.metrics.onFinalized(finalizedBlockNumber)
+
+	finalizedBlockNumber = min(finalizedBlockNumber, poller.endBlockNumber)
+
 	// already caught up
 	if poller.nextBlockNumber > finalizedBlockNumber {
 		return 0, nil
@@ -127,10 +167,9 @@ func (poller *CatchUpPoller[T]) pollOnce(ctx context.Context) (int, error) {
 		"blocks":    blocks,
 	}).Debug("Begin to poll once in catch up mode")
 
-	worker := NewParallelWorker(poller.adapter, poller.nextBlockNumber, poller.dataCh.SendCh(), poller.option.Parallel)
 	start := time.Now()
-	err = parallel.Serial(ctx, worker, blocks, poller.option.Parallel.SerialOption)
-	poller.nextBlockNumber += worker.Polled()
+	polled, err := poller.pollParallel(ctx, finalizedBlockNumber)
+	poller.nextBlockNumber += polled
 	if err != nil {
 		return 0, errors.WithMessage(err, "Failed to poll blockchain data in parallel")
 	}
@@ -143,3 +182,23 @@ func (poller *CatchUpPoller[T]) pollOnce(ctx context.Context) (int, error) {
 
 	return blocks, nil
 }
+
+// pollParallel polls blockchain data in parallel until the given finalized block number, and returns the number
+// of actual polled blocks.
+func (poller *CatchUpPoller[T]) pollParallel(ctx context.Context, finalizedBlockNumber uint64) (uint64, error) {
+	blocks := finalizedBlockNumber - poller.nextBlockNumber + 1
+
+	if adapter, ok := poller.adapter.(RangeAdapter[T]); ok && poller.option.RangeSize > 1 {
+		worker := newRangeParallelWorker(adapter, poller.nextBlockNumber, finalizedBlockNumber, poller.option.RangeSize,
+			poller.dataCh.SendCh(), poller.option.Parallel, poller.limiter, poller.metrics)
+		tasks := (blocks + poller.option.RangeSize - 1) / poller.option.RangeSize
+		err := parallel.Serial(ctx, worker, int(tasks), poller.option.Parallel.SerialOption)
+		return worker.Polled(), err
+	}
+
+	worker := newParallelWorker(poller.adapter, poller.nextBlockNumber, poller.dataCh.SendCh(), poller.option.Parallel,
+		poller.limiter, poller.metrics)
+	err := parallel.Serial(ctx, worker, int(blocks), poller.option.Parallel.SerialOption)
+
+	return worker.Polled(), err
+}
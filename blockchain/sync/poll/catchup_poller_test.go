@@ -0,0 +1,133 @@
+package poll
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Conflux-Chain/go-conflux-util/blockchain/sync/poll/testutil"
+	"github.com/Conflux-Chain/go-conflux-util/parallel"
+	"github.com/stretchr/testify/assert"
+)
+
+type rangeTestAdapter struct {
+	finalized     uint64
+	requests      atomic.Int32
+	rangeRequests atomic.Int32
+	maxRangeTo    atomic.Uint64 // max block number requested in range
+}
+
+func (adapter *rangeTestAdapter) GetFinalizedBlockNumber(ctx context.Context) (uint64, error) {
+	return adapter.finalized, nil
+}
+
+func (adapter *rangeTestAdapter) GetLatestBlockNumber(ctx context.Context) (uint64, error) {
+	return adapter.finalized, nil
+}
+
+func (adapter *rangeTestAdapter) GetBlockData(ctx context.Context, blockNumber uint64) (testutil.Data, error) {
+	adapter.requests.Add(1)
+	return testutil.Data{Number: blockNumber, Hash: fmt.Sprintf("DataHash-%v", blockNumber)}, nil
+}
+
+func (adapter *rangeTestAdapter) GetBlockDataRange(ctx context.Context, from, to uint64) ([]testutil.Data, error) {
+	adapter.rangeRequests.Add(1)
+
+	for {
+		if prev := adapter.maxRangeTo.Load(); to <= prev || adapter.maxRangeTo.CompareAndSwap(prev, to) {
+			break
+		}
+	}
+
+	var result []testutil.Data
+	for bn := from; bn <= to; bn++ {
+		result = append(result, testutil.Data{Number: bn, Hash: fmt.Sprintf("DataHash-%v", bn)})
+	}
+
+	return result, nil
+}
+
+func (adapter *rangeTestAdapter) GetBlockHash(data testutil.Data) string {
+	return data.Hash
+}
+
+func (adapter *rangeTestAdapter) GetParentBlockHash(data testutil.Data) string {
+	return data.ParentHash
+}
+
+func catchUp(adapter Adapter[testutil.Data], nextBlockNumber uint64, option CatchUpOption) []uint64 {
+	poller := NewCatchUpPoller(adapter, nextBlockNumber, option)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go poller.Poll(context.Background(), &wg)
+
+	var numbers []uint64
+	for data := range poller.DataCh() {
+		numbers = append(numbers, data.Number)
+	}
+
+	wg.Wait()
+
+	return numbers
+}
+
+func TestCatchUpPollerRange(t *testing.T) {
+	adapter := rangeTestAdapter{finalized: 9}
+
+	numbers := catchUp(&adapter, 0, CatchUpOption{RangeSize: 4})
+	assert.Equal(t, []uint64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, numbers)
+	assert.Equal(t, int32(0), adapter.requests.Load())
+	assert.Equal(t, int32(3), adapter.rangeRequests.Load())
+	assert.Equal(t, uint64(9), adapter.maxRangeTo.Load())
+
+	// range crosses the finalized block
+	adapter.rangeRequests.Store(0)
+	numbers = catchUp(&adapter, 7, CatchUpOption{RangeSize: 10})
+	assert.Equal(t, []uint64{7, 8, 9}, numbers)
+	assert.Equal(t, int32(1), adapter.rangeRequests.Load())
+	assert.Equal(t, uint64(9), adapter.maxRangeTo.Load())
+
+	// poll block by block
+	adapter.rangeRequests.Store(0)
+	numbers = catchUp(&adapter, 5, CatchUpOption{RangeSize: 1})
+	assert.Equal(t, []uint64{5, 6, 7, 8, 9}, numbers)
+	assert.Equal(t, int32(5), adapter.requests.Load())
+	assert.Equal(t, int32(0), adapter.rangeRequests.Load())
+}
+
+func TestCatchUpPollerAdaptive(t *testing.T) {
+	adapter := rangeTestAdapter{finalized: 9}
+
+	numbers := catchUp(&adapter, 0, CatchUpOption{
+		Parallel: ParallelOption{
+			SerialOption: parallel.SerialOption{Routines: 4},
+			Adaptive:     parallel.AdaptiveOption{Enabled: true},
+		},
+		RangeSize: 1,
+	})
+	assert.Equal(t, []uint64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, numbers)
+	assert.Equal(t, int32(10), adapter.requests.Load())
+}
+
+func TestBoundedCatchUpPoller(t *testing.T) {
+	adapter := rangeTestAdapter{finalized: 9}
+
+	poller := NewBoundedCatchUpPoller[testutil.Data](&adapter, 3, 6, CatchUpOption{RangeSize: 2})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go poller.Poll(context.Background(), &wg)
+
+	var numbers []uint64
+	for data := range poller.DataCh() {
+		numbers = append(numbers, data.Number)
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, []uint64{3, 4, 5, 6}, numbers)
+	assert.Equal(t, uint64(7), poller.NextBlockNumber())
+}
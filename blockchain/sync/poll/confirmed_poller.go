@@ -0,0 +1,184 @@
+package poll
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/Conflux-Chain/go-conflux-util/alert"
+	"github.com/Conflux-Chain/go-conflux-util/ctxutil"
+	"github.com/Conflux-Chain/go-conflux-util/log"
+	"github.com/pkg/errors"
+)
+
+// ErrDeepReorg is returned when chain reorg is deeper than the number of confirmations, in which case
+// the confirmed blocks that already emitted are reverted.
+var ErrDeepReorg = errors.New("Chain reorg deeper than confirmations")
+
+type confirmedBlock[T any] struct {
+	number uint64
+	data   T
+}
+
+// ConfirmedPoller is used to poll the latest blockchain data block by block, and emits each block exactly once
+// after N confirmations, i.e. block n will be emitted once block n+N has been built on it.
+//
+// It keeps a small buffer of unconfirmed blocks to handle chain reorg internally. If chain reorg is deeper than
+// N blocks, the poller will terminate with ErrDeepReorg.
+type ConfirmedPoller[T any] struct {
+	option          Option
+	confirmations   uint64
+	latest          *LatestPoller[T]
+	nextBlockNumber atomic.Uint64 // the next block number to emit
+	dataCh          chan T
+	buffer          []confirmedBlock[T] // unconfirmed blocks in sequence
+	err             error
+}
+
+func NewConfirmedPoller[T any](
+	adapter Adapter[T], nextBlockNumber uint64, reorgParams ReorgWindowParams, confirmations uint64, option ...Option,
+) (*ConfirmedPoller[T], error) {
+	opt := normalizeOpt(option...)
+
+	latest, err := NewLatestPoller(adapter, nextBlockNumber, reorgParams, opt)
+	if err != nil {
+		return nil, err
+	}
+
+	poller := ConfirmedPoller[T]{
+		option:        opt,
+		confirmations: confirmations,
+		latest:        latest,
+		dataCh:        make(chan T, opt.BufferSize),
+	}
+
+	poller.nextBlockNumber.Store(nextBlockNumber)
+
+	return &poller, nil
+}
+
+// DataCh returns a read-only channel to consume data. The channel will not be closed
+// until poll goroutine terminated.
+func (poller *ConfirmedPoller[T]) DataCh() <-chan T {
+	return poller.dataCh
+}
+
+// NextBlockNumber returns the next block number to emit data, which is thread-safe.
+func (poller *ConfirmedPoller[T]) NextBlockNumber() uint64 {
+	return poller.nextBlockNumber.Load()
+}
+
+// Err returns the error that terminated the poller, e.g. ErrDeepReorg. It should be called after
+// the data channel closed.
+func (poller *ConfirmedPoller[T]) Err() error {
+	return poller.err
+}
+
+// Poll polls the latest blockchain data block by block, and emits data once confirmed.
+func (poller *ConfirmedPoller[T]) Poll(ctx context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	// close channel if completed
+	defer close(poller.dataCh)
+
+	var latestWg sync.WaitGroup
+	latestCtx, cancel := context.WithCancel(ctx)
+	defer latestWg.Wait()
+	defer cancel()
+
+	latestWg.Add(1)
+	go poller.latest.Poll(latestCtx, &latestWg)
+
+	nextLatest := poller.nextBlockNumber.Load()
+
+	for {
+		var data Revertable[T]
+		var ok bool
+
+		select {
+		case <-ctx.Done():
+			return
+		case data, ok = <-poller.latest.DataCh():
+			if !ok {
+				return
+			}
+		}
+
+		if data.Rewind != nil {
+			if poller.err = poller.rewind(*data.Rewind); poller.err != nil {
+				return
+			}
+
+			nextLatest = data.Rewind.ForkBlockNumber + 1
+		}
+
+		poller.buffer = append(poller.buffer, confirmedBlock[T]{nextLatest, data.Data})
+		nextLatest++
+
+		if err := poller.emit(ctx); err != nil {
+			return
+		}
+	}
+}
+
+// rewind removes the orphaned blocks from buffer, and returns ErrDeepReorg if any emitted block reverted.
+func (poller *ConfirmedPoller[T]) rewind(rewind Rewind) error {
+	if nextBlockNumber := poller.nextBlockNumber.Load(); rewind.ForkBlockNumber+1 < nextBlockNumber {
+		err := errors.WithMessagef(ErrDeepReorg, "confirmations = %v, fork = %v, next = %v, orphaned = %v",
+			poller.confirmations, rewind.ForkBlockNumber, nextBlockNumber, len(rewind.Orphaned))
+
+		log.WithModule(ModuleName).WithError(err).Error("Confirmed blocks reverted")
+
+		go poller.alert(err)
+
+		return err
+	}
+
+	for len(poller.buffer) > 0 && poller.buffer[len(poller.buffer)-1].number > rewind.ForkBlockNumber {
+		poller.buffer = poller.buffer[:len(poller.buffer)-1]
+	}
+
+	return nil
+}
+
+// emit writes the confirmed blocks into data channel.
+func (poller *ConfirmedPoller[T]) emit(ctx context.Context) error {
+	for len(poller.buffer) > 0 && poller.buffer[0].number+poller.confirmations <= poller.buffer[len(poller.buffer)-1].number {
+		if err := ctxutil.WriteChannel(ctx, poller.dataCh, poller.buffer[0].data); err != nil {
+			return err
+		}
+
+		log.WithModule(ModuleName).WithField("block", poller.buffer[0].number).Trace("Succeeded to poll confirmed data")
+
+		poller.buffer = poller.buffer[1:]
+		poller.nextBlockNumber.Add(1)
+	}
+
+	return nil
+}
+
+func (poller *ConfirmedPoller[T]) alert(err error) {
+	if poller.option.Reorg.AlertChannel == "" {
+		return
+	}
+
+	channel, ok := alert.DefaultManager().Channel(poller.option.Reorg.AlertChannel)
+	if !ok {
+		log.WithModule(ModuleName).WithField("channel", poller.option.Reorg.AlertChannel).Warn("Alert channel not found for chain reorg")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), poller.option.Reorg.AlertTimeout)
+	defer cancel()
+
+	err = channel.Send(ctx, &alert.Notification{
+		Title:    "Confirmed blocks reverted",
+		Content:  fmt.Sprint(err),
+		Severity: alert.SeverityCritical,
+	})
+
+	if err != nil {
+		log.WithModule(ModuleName).WithError(err).Warn("Failed to send alert for confirmed blocks reverted")
+	}
+}
@@ -0,0 +1,82 @@
+package poll
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Conflux-Chain/go-conflux-util/blockchain/sync/poll/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestConfirmedPoller(t *testing.T, confirmations uint64, latestBlocks ...testutil.Data) *ConfirmedPoller[testutil.Data] {
+	adapter := testutil.MustNewAdapter([]uint64{5}, latestBlocks)
+
+	poller, err := NewConfirmedPoller(adapter, 6, ReorgWindowParams{
+		FinalizedBlockNumber: 5,
+		FinalizedBlockHash:   "DataHash-5",
+	}, confirmations, Option{IdleInterval: time.Millisecond, RetryInterval: time.Millisecond})
+	assert.NoError(t, err)
+
+	return poller
+}
+
+func TestConfirmedPollerReorgInBuffer(t *testing.T) {
+	poller := newTestConfirmedPoller(t, 2,
+		testutil.Data{Number: 6, Hash: "DataHash-6", ParentHash: "DataHash-5"},
+		testutil.Data{Number: 7, Hash: "DataHash-7", ParentHash: "DataHash-6"},
+		testutil.Data{Number: 8, Hash: "DataHash-8", ParentHash: "DataHash-7"},
+
+		// revert block 7 and 8
+		testutil.Data{Number: 9, Hash: "DataHash-99", ParentHash: "DataHash-88"},
+		testutil.Data{Number: 8, Hash: "DataHash-88", ParentHash: "DataHash-77"},
+		testutil.Data{Number: 7, Hash: "DataHash-77", ParentHash: "DataHash-6"},
+		testutil.Data{Number: 8, Hash: "DataHash-88", ParentHash: "DataHash-77"},
+		testutil.Data{Number: 9, Hash: "DataHash-99", ParentHash: "DataHash-88"},
+		testutil.Data{Number: 10, Hash: "DataHash-10", ParentHash: "DataHash-99"},
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go poller.Poll(ctx, &wg)
+
+	assert.Equal(t, testutil.Data{Number: 6, Hash: "DataHash-6", ParentHash: "DataHash-5"}, <-poller.DataCh())
+	assert.Equal(t, testutil.Data{Number: 7, Hash: "DataHash-77", ParentHash: "DataHash-6"}, <-poller.DataCh())
+	assert.Equal(t, testutil.Data{Number: 8, Hash: "DataHash-88", ParentHash: "DataHash-77"}, <-poller.DataCh())
+	assert.Equal(t, uint64(9), poller.NextBlockNumber())
+
+	cancel()
+	wg.Wait()
+
+	assert.NoError(t, poller.Err())
+}
+
+func TestConfirmedPollerDeepReorg(t *testing.T) {
+	poller := newTestConfirmedPoller(t, 1,
+		testutil.Data{Number: 6, Hash: "DataHash-6", ParentHash: "DataHash-5"},
+		testutil.Data{Number: 7, Hash: "DataHash-7", ParentHash: "DataHash-6"},
+
+		// revert block 6 that already emitted
+		testutil.Data{Number: 8, Hash: "DataHash-88", ParentHash: "DataHash-77"},
+		testutil.Data{Number: 7, Hash: "DataHash-77", ParentHash: "DataHash-66"},
+		testutil.Data{Number: 6, Hash: "DataHash-66", ParentHash: "DataHash-5"},
+		testutil.Data{Number: 7, Hash: "DataHash-77", ParentHash: "DataHash-66"},
+		testutil.Data{Number: 8, Hash: "DataHash-88", ParentHash: "DataHash-77"},
+	)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go poller.Poll(context.Background(), &wg)
+
+	assert.Equal(t, testutil.Data{Number: 6, Hash: "DataHash-6", ParentHash: "DataHash-5"}, <-poller.DataCh())
+
+	// channel closed once poller terminated
+	_, ok := <-poller.DataCh()
+	assert.False(t, ok)
+
+	wg.Wait()
+
+	assert.ErrorIs(t, poller.Err(), ErrDeepReorg)
+}
@@ -3,6 +3,7 @@ package poll
 import (
 	"context"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/Conflux-Chain/go-conflux-util/ctxutil"
@@ -16,27 +17,41 @@ type Option struct {
 	RetryInterval time.Duration `default:"5s"`
 	BufferSize    int           `default:"32"`
 	Health        health.TimedCounterConfig
+
+	// Subscription is used by LatestPoller to poll the latest data once new block arrived.
+	Subscription SubscriptionOption
+
+	// Reorg is used by LatestPoller to report chain reorg via metrics and alert.
+	Reorg ReorgOption
+
+	Metrics MetricsOption
 }
 
 // FinalizedPoller is used to poll the finalized blockchain data block by block.
 type FinalizedPoller[T any] struct {
 	option          Option
 	adapter         Adapter[T]
-	nextBlockNumber uint64
+	nextBlockNumber atomic.Uint64
 	dataCh          chan T
 	health          *health.TimedCounter
+	metrics         *pollerMetrics
 }
 
 func NewFinalizedPoller[T any](adapter Adapter[T], nextBlockNumber uint64, option ...Option) *FinalizedPoller[T] {
 	opt := normalizeOpt(option...)
 
-	return &FinalizedPoller[T]{
-		option:          opt,
-		adapter:         adapter,
-		nextBlockNumber: nextBlockNumber,
-		dataCh:          make(chan T, opt.BufferSize),
-		health:          health.NewTimedCounter(opt.Health),
+	poller := FinalizedPoller[T]{
+		option:  opt,
+		adapter: adapter,
+		dataCh:  make(chan T, opt.BufferSize),
+		health:  health.NewTimedCounter(opt.Health),
+		metrics: newPollerMetrics(opt.Metrics, "finalized"),
 	}
+
+	poller.nextBlockNumber.Store(nextBlockNumber)
+	poller.metrics.onNext(nextBlockNumber)
+
+	return &poller
 }
 
 // DataCh returns a read-only channel to consume data. The channel will not be closed
@@ -45,6 +60,11 @@ func (poller *FinalizedPoller[T]) DataCh() <-chan T {
 	return poller.dataCh
 }
 
+// NextBlockNumber returns the next block number to poll data, which is thread-safe.
+func (poller *FinalizedPoller[T]) NextBlockNumber() uint64 {
+	return poller.nextBlockNumber.Load()
+}
+
 // Poll polls the finalized blockchain data block by block.
 func (poller *FinalizedPoller[T]) Poll(ctx context.Context, wg *sync.WaitGroup) {
 	defer wg.Done()
@@ -62,15 +82,16 @@ func (poller *FinalizedPoller[T]) Poll(ctx context.Context, wg *sync.WaitGroup)
 
 		poller.health.LogOnError(err, "Poll finalized blockchain data")
 
-		logger := log.WithModule(ModuleName).WithField("block", poller.nextBlockNumber)
+		logger := log.WithModule(ModuleName).WithField("block", poller.nextBlockNumber.Load())
 
 		if err != nil {
 			logger.WithError(err).Debug("Failed to poll finalized data")
 			err = ctxutil.Sleep(ctx, poller.option.RetryInterval)
 		} else if ok {
 			logger.Trace("Succeeded to poll finalized data")
-			err = ctxutil.WriteChannel(ctx, poller.dataCh, data)
-			poller.nextBlockNumber++
+			if err = ctxutil.WriteChannel(ctx, poller.dataCh, data); err == nil {
+				poller.metrics.onPolled(poller.nextBlockNumber.Add(1))
+			}
 		} else {
 			logger.Trace("No finalized data to poll")
 			err = ctxutil.Sleep(ctx, poller.option.IdleInterval)
@@ -90,13 +111,16 @@ func (poller *FinalizedPoller[T]) pollOnce(ctx context.Context) (data T, ok bool
 		return data, false, errors.WithMessage(err, "Failed to get finalized block number")
 	}
 
+	poller.metrics.onFinalized(finalizedBlockNumber)
+
 	// already caught up
-	if poller.nextBlockNumber > finalizedBlockNumber {
+	nextBlockNumber := poller.nextBlockNumber.Load()
+	if nextBlockNumber > finalizedBlockNumber {
 		return data, false, nil
 	}
 
 	// retrieve the next blockchain data
-	if data, err = poller.adapter.GetBlockData(ctx, poller.nextBlockNumber); err != nil {
+	if data, err = timedGetBlockData(ctx, poller.adapter, nextBlockNumber, poller.metrics); err != nil {
 		return data, false, errors.WithMessage(err, "Failed to retrieve blockchain data")
 	}
 
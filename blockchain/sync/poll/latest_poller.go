@@ -2,28 +2,74 @@ package poll
 
 import (
 	"context"
+	"fmt"
+	"slices"
 	"sync"
+	"sync/atomic"
 
 	"github.com/Conflux-Chain/go-conflux-util/ctxutil"
 	"github.com/Conflux-Chain/go-conflux-util/health"
 	"github.com/Conflux-Chain/go-conflux-util/log"
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 )
 
 type Revertable[T any] struct {
 	Data     T
-	Reverted bool // indicates whether chain reorg happened
+	Reverted bool    // indicates whether chain reorg happened
+	Rewind   *Rewind // details of chain reorg, nil if not reverted
 }
 
+// OrphanedBlock is a block reverted due to chain reorg.
+type OrphanedBlock struct {
+	Number uint64
+	Hash   string
+}
+
+// Rewind represents a chain reorg, in which all blocks after the fork block are orphaned.
+type Rewind struct {
+	ForkBlockNumber uint64          // the common ancestor block, which is not reverted
+	ForkBlockHash   string          // hash of the common ancestor block
+	Orphaned        []OrphanedBlock // orphaned blocks in ascending order of block number
+}
+
+// ReorgError is returned when failed to push the polled block into reorg window, e.g. fullnode reports an
+// inconsistent finalized block temporarily.
+type ReorgError struct {
+	BlockNumber     uint64
+	BlockHash       string
+	ParentBlockHash string
+	Window          string // reorg window in string format
+	Err             error  // ErrBlockNotInSequence or ErrFinalizedBlockReverted
+	Consecutive     int    // number of consecutive reorg errors since the last block pushed, including this one
+}
+
+func (e *ReorgError) Error() string {
+	return fmt.Sprintf("%v, block = %v, hash = %v, parent = %v, window = %v",
+		e.Err, e.BlockNumber, e.BlockHash, e.ParentBlockHash, e.Window)
+}
+
+func (e *ReorgError) Unwrap() error {
+	return e.Err
+}
+
+// ReorgErrorHandler handles the reorg error, and returns true to poll again after RetryInterval, or false to
+// terminate the poller.
+type ReorgErrorHandler func(err *ReorgError) (retry bool)
+
 // LatestPoller is used to poll the latest blockchain data block by block.
 type LatestPoller[T any] struct {
 	option          Option
 	adapter         Adapter[T]
-	nextBlockNumber uint64
+	nextBlockNumber atomic.Uint64
 	dataCh          chan Revertable[T]
 	window          *ReorgWindow
 	health          *health.TimedCounter
+	waiter          *waiter
+	reorgMonitor    *reorgMonitor
+	metrics         *pollerMetrics
+	onReorgError    ReorgErrorHandler
+	reorgErrors     int             // number of consecutive reorg errors
+	orphaned        []OrphanedBlock // popped blocks of the ongoing chain reorg in descending order
 }
 
 func NewLatestPoller[T any](adapter Adapter[T], nextBlockNumber uint64, reorgParams ReorgWindowParams, option ...Option) (*LatestPoller[T], error) {
@@ -34,14 +80,30 @@ func NewLatestPoller[T any](adapter Adapter[T], nextBlockNumber uint64, reorgPar
 		return nil, errors.WithMessage(err, "Failed to create reorg window")
 	}
 
-	return &LatestPoller[T]{
-		option:          opt,
-		adapter:         adapter,
-		nextBlockNumber: nextBlockNumber,
-		dataCh:          make(chan Revertable[T], opt.BufferSize),
-		window:          window,
-		health:          health.NewTimedCounter(opt.Health),
-	}, nil
+	poller := LatestPoller[T]{
+		option:       opt,
+		adapter:      adapter,
+		dataCh:       make(chan Revertable[T], opt.BufferSize),
+		window:       window,
+		health:       health.NewTimedCounter(opt.Health),
+		waiter:       newWaiter(adapter, opt),
+		reorgMonitor: newReorgMonitor(opt.Reorg, opt.Metrics),
+		metrics:      newPollerMetrics(opt.Metrics, "latest", true),
+	}
+
+	poller.nextBlockNumber.Store(nextBlockNumber)
+	poller.metrics.onNext(nextBlockNumber)
+
+	return &poller, nil
+}
+
+// SetReorgErrorHandler sets the handler to decide whether to retry or terminate once reorg error occurred,
+// which should be called before polling.
+//
+// By default, poller will poll again after RetryInterval, in case that fullnode reports inconsistent data
+// temporarily. Note, the underlying adapter could be a MultiAdapter, so as to poll from another fullnode.
+func (poller *LatestPoller[T]) SetReorgErrorHandler(handler ReorgErrorHandler) {
+	poller.onReorgError = handler
 }
 
 // DataCh returns a read-only channel to consume data. The channel will not be closed
@@ -50,6 +112,11 @@ func (poller *LatestPoller[T]) DataCh() <-chan Revertable[T] {
 	return poller.dataCh
 }
 
+// NextBlockNumber returns the next block number to poll data, which is thread-safe.
+func (poller *LatestPoller[T]) NextBlockNumber() uint64 {
+	return poller.nextBlockNumber.Load()
+}
+
 // Poll polls the latest blockchain data block by block.
 func (poller *LatestPoller[T]) Poll(ctx context.Context, wg *sync.WaitGroup) {
 	defer wg.Done()
@@ -57,8 +124,6 @@ func (poller *LatestPoller[T]) Poll(ctx context.Context, wg *sync.WaitGroup) {
 	// close channel if completed
 	defer close(poller.dataCh)
 
-	var reverted bool
-
 	for {
 		data, ok, reorg, err := poller.pollOnce(ctx)
 
@@ -69,27 +134,40 @@ func (poller *LatestPoller[T]) Poll(ctx context.Context, wg *sync.WaitGroup) {
 
 		poller.health.LogOnError(err, "Poll latest blockchain data")
 
-		logger := log.WithModule(ModuleName).WithField("block", poller.nextBlockNumber)
+		logger := log.WithModule(ModuleName).WithField("block", poller.nextBlockNumber.Load())
 
-		if err != nil {
+		if reorgErr := (*ReorgError)(nil); errors.As(err, &reorgErr) {
+			logger.WithError(err).Warn("Failed to push block into reorg window")
+
+			if poller.onReorgError != nil && !poller.onReorgError(reorgErr) {
+				return
+			}
+
+			err = ctxutil.Sleep(ctx, poller.option.RetryInterval)
+		} else if err != nil {
 			logger.WithError(err).Debug("Failed to poll latest data")
 			err = ctxutil.Sleep(ctx, poller.option.RetryInterval)
 		} else if ok {
 			logger.Trace("Succeeded to poll latest data")
 			err = ctxutil.WriteChannel(ctx, poller.dataCh, Revertable[T]{
 				Data:     data,
-				Reverted: reverted,
+				Reverted: len(poller.orphaned) > 0,
+				Rewind:   poller.rewind(data),
 			})
 
-			poller.nextBlockNumber++
-			reverted = false
+			if err == nil {
+				poller.reorgMonitor.onAppended(poller.nextBlockNumber.Load())
+				poller.metrics.onPolled(poller.nextBlockNumber.Add(1))
+				poller.orphaned = nil
+			}
 		} else if reorg {
 			logger.Debug("Reorg detected")
-			poller.nextBlockNumber--
-			reverted = true
+			popped := poller.nextBlockNumber.Add(^uint64(0))
+			poller.reorgMonitor.onPopped(popped)
+			poller.metrics.onNext(popped)
 		} else {
 			logger.Trace("No latest data to poll")
-			err = ctxutil.Sleep(ctx, poller.option.IdleInterval)
+			err = poller.waiter.wait(ctx)
 		}
 
 		// context done
@@ -107,6 +185,7 @@ func (poller *LatestPoller[T]) pollOnce(ctx context.Context) (data T, ok bool, r
 	}
 
 	poller.window.Evict(finalizedBlockNumber)
+	poller.metrics.onFinalized(finalizedBlockNumber)
 
 	// get the latest block number
 	latestBlockNumber, err := poller.adapter.GetLatestBlockNumber(ctx)
@@ -114,30 +193,60 @@ func (poller *LatestPoller[T]) pollOnce(ctx context.Context) (data T, ok bool, r
 		return data, false, false, errors.WithMessage(err, "Failed to get latest block number")
 	}
 
+	poller.metrics.onLatest(latestBlockNumber)
+
 	// already caught up
-	if poller.nextBlockNumber > latestBlockNumber {
+	nextBlockNumber := poller.nextBlockNumber.Load()
+	if nextBlockNumber > latestBlockNumber {
 		return data, false, false, nil
 	}
 
 	// retrieve the next blockchain data
-	if data, err = poller.adapter.GetBlockData(ctx, poller.nextBlockNumber); err != nil {
+	if data, err = timedGetBlockData(ctx, poller.adapter, nextBlockNumber, poller.metrics); err != nil {
 		return data, false, false, errors.WithMessage(err, "Failed to retrieve blockchain data")
 	}
 
 	// detect reorg
 	blockHash := poller.adapter.GetBlockHash(data)
 	parentBlockHash := poller.adapter.GetParentBlockHash(data)
-	appended, popped, err := poller.window.Push(poller.nextBlockNumber, blockHash, parentBlockHash)
+	latest := OrphanedBlock{poller.window.latest, poller.window.blockNumber2Hashes[poller.window.latest]}
+	appended, popped, err := poller.window.Push(nextBlockNumber, blockHash, parentBlockHash)
 
-	// should never happen
+	// fullnode may report inconsistent data temporarily
 	if err != nil {
-		log.WithModule(ModuleName).WithError(err).WithFields(logrus.Fields{
-			"block":  poller.nextBlockNumber,
-			"hash":   blockHash,
-			"parent": parentBlockHash,
-			"window": poller.window,
-		}).Fatal("Block not in sequence or finalized block reverted")
+		poller.reorgErrors++
+
+		return data, false, false, &ReorgError{
+			BlockNumber:     nextBlockNumber,
+			BlockHash:       blockHash,
+			ParentBlockHash: parentBlockHash,
+			Window:          poller.window.String(),
+			Err:             err,
+			Consecutive:     poller.reorgErrors,
+		}
+	}
+
+	poller.reorgErrors = 0
+
+	if popped {
+		poller.orphaned = append(poller.orphaned, latest)
 	}
 
 	return data, appended, popped, nil
 }
+
+// rewind returns the chain reorg details if the given data is appended after any block popped.
+func (poller *LatestPoller[T]) rewind(data T) *Rewind {
+	if len(poller.orphaned) == 0 {
+		return nil
+	}
+
+	orphaned := slices.Clone(poller.orphaned)
+	slices.Reverse(orphaned)
+
+	return &Rewind{
+		ForkBlockNumber: orphaned[0].Number - 1,
+		ForkBlockHash:   poller.adapter.GetParentBlockHash(data),
+		Orphaned:        orphaned,
+	}
+}
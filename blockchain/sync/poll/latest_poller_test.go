@@ -0,0 +1,146 @@
+package poll
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Conflux-Chain/go-conflux-util/blockchain/sync/poll/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestLatestPoller(t *testing.T, latestBlocks ...testutil.Data) *LatestPoller[testutil.Data] {
+	adapter := testutil.MustNewAdapter([]uint64{5}, latestBlocks)
+
+	poller, err := NewLatestPoller(adapter, 6, ReorgWindowParams{
+		FinalizedBlockNumber: 5,
+		FinalizedBlockHash:   "DataHash-5",
+	}, Option{IdleInterval: time.Millisecond, RetryInterval: time.Millisecond})
+	assert.NoError(t, err)
+
+	return poller
+}
+
+func TestLatestPollerReorgErrorRetry(t *testing.T) {
+	poller := newTestLatestPoller(t,
+		testutil.Data{Number: 6, Hash: "DataHash-66", ParentHash: "DataHash-55"}, // finalized block reverted
+		testutil.Data{Number: 6, Hash: "DataHash-6", ParentHash: "DataHash-5"},
+	)
+
+	var reorgErrors []*ReorgError
+	poller.SetReorgErrorHandler(func(err *ReorgError) bool {
+		reorgErrors = append(reorgErrors, err)
+		return true
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go poller.Poll(ctx, &wg)
+
+	data := <-poller.DataCh()
+	assert.Equal(t, testutil.Data{Number: 6, Hash: "DataHash-6", ParentHash: "DataHash-5"}, data.Data)
+	assert.False(t, data.Reverted)
+
+	cancel()
+	wg.Wait()
+
+	assert.Equal(t, 1, len(reorgErrors))
+	assert.ErrorIs(t, reorgErrors[0], ErrFinalizedBlockReverted)
+	assert.Equal(t, uint64(6), reorgErrors[0].BlockNumber)
+	assert.Equal(t, "DataHash-55", reorgErrors[0].ParentBlockHash)
+}
+
+func TestLatestPollerReorgErrorConsecutive(t *testing.T) {
+	poller := newTestLatestPoller(t,
+		testutil.Data{Number: 6, Hash: "DataHash-66", ParentHash: "DataHash-55"}, // finalized block reverted
+		testutil.Data{Number: 6, Hash: "DataHash-66", ParentHash: "DataHash-55"}, // finalized block reverted
+		testutil.Data{Number: 6, Hash: "DataHash-6", ParentHash: "DataHash-5"},
+		testutil.Data{Number: 7, Hash: "DataHash-77", ParentHash: "DataHash-66"}, // pop block 6
+		testutil.Data{Number: 6, Hash: "DataHash-66", ParentHash: "DataHash-55"}, // finalized block reverted
+		testutil.Data{Number: 6, Hash: "DataHash-66", ParentHash: "DataHash-5"},
+		testutil.Data{Number: 7, Hash: "DataHash-77", ParentHash: "DataHash-66"},
+	)
+
+	var consecutive []int
+	poller.SetReorgErrorHandler(func(err *ReorgError) bool {
+		consecutive = append(consecutive, err.Consecutive)
+		return true
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go poller.Poll(ctx, &wg)
+
+	data := <-poller.DataCh()
+	assert.Equal(t, "DataHash-6", data.Data.Hash)
+
+	data = <-poller.DataCh()
+	assert.Equal(t, "DataHash-66", data.Data.Hash)
+	assert.True(t, data.Reverted)
+
+	cancel()
+	wg.Wait()
+
+	// reset once block pushed into reorg window
+	assert.Equal(t, []int{1, 2, 1}, consecutive)
+}
+
+func TestLatestPollerReorgErrorTerminate(t *testing.T) {
+	poller := newTestLatestPoller(t,
+		testutil.Data{Number: 6, Hash: "DataHash-66", ParentHash: "DataHash-55"}, // finalized block reverted
+	)
+
+	poller.SetReorgErrorHandler(func(err *ReorgError) bool {
+		return false
+	})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go poller.Poll(context.Background(), &wg)
+
+	// channel closed once poller terminated
+	_, ok := <-poller.DataCh()
+	assert.False(t, ok)
+
+	wg.Wait()
+}
+
+func TestLatestPollerRewind(t *testing.T) {
+	poller := newTestLatestPoller(t,
+		testutil.Data{Number: 6, Hash: "DataHash-6", ParentHash: "DataHash-5"},
+		testutil.Data{Number: 7, Hash: "DataHash-7", ParentHash: "DataHash-6"},
+
+		// revert block 6 and 7
+		testutil.Data{Number: 8, Hash: "DataHash-88", ParentHash: "DataHash-77"},
+		testutil.Data{Number: 7, Hash: "DataHash-77", ParentHash: "DataHash-66"},
+		testutil.Data{Number: 6, Hash: "DataHash-66", ParentHash: "DataHash-5"},
+		testutil.Data{Number: 7, Hash: "DataHash-77", ParentHash: "DataHash-66"},
+		testutil.Data{Number: 8, Hash: "DataHash-88", ParentHash: "DataHash-77"},
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go poller.Poll(ctx, &wg)
+
+	for i := 0; i < 2; i++ {
+		data := <-poller.DataCh()
+		assert.False(t, data.Reverted)
+		assert.Nil(t, data.Rewind)
+	}
+
+	data := <-poller.DataCh()
+	assert.Equal(t, testutil.Data{Number: 6, Hash: "DataHash-66", ParentHash: "DataHash-5"}, data.Data)
+	assert.True(t, data.Reverted)
+	assert.Equal(t, &Rewind{
+		ForkBlockNumber: 5,
+		ForkBlockHash:   "DataHash-5",
+		Orphaned:        []OrphanedBlock{{6, "DataHash-6"}, {7, "DataHash-7"}},
+	}, data.Rewind)
+
+	cancel()
+	wg.Wait()
+}
@@ -0,0 +1,118 @@
+package poll
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/Conflux-Chain/go-conflux-util/metrics"
+	gometrics "github.com/rcrowley/go-metrics"
+)
+
+// MetricsOption is used to namespace metrics of a sync instance, so that multiple sync instances,
+// e.g. multiple chains, could run in one process.
+type MetricsOption struct {
+	// Namespace is used in metric names as "sync/{namespace}/...", and empty indicates "sync/...".
+	Namespace string
+}
+
+// Name returns the full metric name with namespace, e.g. "sync/espace/poll/latest/lag".
+func (option MetricsOption) Name(nameFormat string, nameArgs ...any) string {
+	name := fmt.Sprintf(nameFormat, nameArgs...)
+
+	if len(option.Namespace) == 0 {
+		return fmt.Sprintf("sync/%v", name)
+	}
+
+	return fmt.Sprintf("sync/%v/%v", option.Namespace, name)
+}
+
+// pollerMetrics reports the poll progress of a poller, e.g. next block number, lag and throughput.
+type pollerMetrics struct {
+	option       MetricsOption
+	phase        string
+	followLatest bool // whether the target block is the latest block, otherwise, the finalized block
+
+	next      gometrics.Gauge
+	finalized gometrics.Gauge
+	latest    gometrics.Gauge
+	lag       gometrics.Gauge
+	blocks    gometrics.Meter
+	rpc       gometrics.Timer // latency to retrieve blockchain data
+
+	nextBlockNumber   atomic.Uint64
+	targetBlockNumber atomic.Uint64
+}
+
+func newPollerMetrics(option MetricsOption, phase string, followLatest ...bool) *pollerMetrics {
+	m := pollerMetrics{
+		option:    option,
+		phase:     phase,
+		next:      metrics.GetOrRegisterGauge("%v", option.Name("poll/%v/next", phase)),
+		finalized: metrics.GetOrRegisterGauge("%v", option.Name("poll/%v/finalized", phase)),
+		lag:       metrics.GetOrRegisterGauge("%v", option.Name("poll/%v/lag", phase)),
+		blocks:    metrics.GetOrRegisterMeter("%v", option.Name("poll/%v/blocks", phase)),
+		rpc:       metrics.GetOrRegisterTimer("%v", option.Name("poll/%v/rpc", phase)),
+	}
+
+	if len(followLatest) > 0 && followLatest[0] {
+		m.followLatest = true
+		m.latest = metrics.GetOrRegisterGauge("%v", option.Name("poll/%v/latest", phase))
+	}
+
+	return &m
+}
+
+// onFinalized should be called once the finalized block number retrieved.
+func (m *pollerMetrics) onFinalized(blockNumber uint64) {
+	m.finalized.Update(int64(blockNumber))
+
+	if !m.followLatest {
+		m.targetBlockNumber.Store(blockNumber)
+		m.updateLag()
+	}
+}
+
+// onLatest should be called once the latest block number retrieved.
+func (m *pollerMetrics) onLatest(blockNumber uint64) {
+	if !m.followLatest {
+		return
+	}
+
+	m.latest.Update(int64(blockNumber))
+	m.targetBlockNumber.Store(blockNumber)
+	m.updateLag()
+}
+
+// onPolled should be called once a block polled, and the next block number to poll is given.
+func (m *pollerMetrics) onPolled(nextBlockNumber uint64) {
+	m.blocks.Mark(1)
+	m.onNext(nextBlockNumber)
+}
+
+// onNext should be called once the next block number to poll changed, e.g. chain reorg happened.
+func (m *pollerMetrics) onNext(nextBlockNumber uint64) {
+	m.nextBlockNumber.Store(nextBlockNumber)
+	m.next.Update(int64(nextBlockNumber))
+	m.updateLag()
+}
+
+func (m *pollerMetrics) updateLag() {
+	var lag int64
+
+	if target, next := m.targetBlockNumber.Load(), m.nextBlockNumber.Load(); target >= next {
+		lag = int64(target - next + 1)
+	}
+
+	m.lag.Update(lag)
+}
+
+// timedGetBlockData retrieves blockchain data of the given block number, and reports the latency in metrics.
+func timedGetBlockData[T any](ctx context.Context, adapter Adapter[T], blockNumber uint64, m *pollerMetrics) (T, error) {
+	start := time.Now()
+	data, err := adapter.GetBlockData(ctx, blockNumber)
+	m.rpc.UpdateSince(start)
+
+	return data, err
+}
@@ -0,0 +1,33 @@
+package poll
+
+import (
+	"testing"
+
+	gometrics "github.com/rcrowley/go-metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetricsOptionName(t *testing.T) {
+	assert.Equal(t, "sync/poll/latest/lag", MetricsOption{}.Name("poll/%v/lag", "latest"))
+	assert.Equal(t, "sync/espace/poll/latest/lag", MetricsOption{"espace"}.Name("poll/%v/lag", "latest"))
+}
+
+func TestPollerMetricsLag(t *testing.T) {
+	m := newPollerMetrics(MetricsOption{"test"}, "latest", true)
+
+	m.onNext(10)
+	m.onFinalized(15)
+	assert.Equal(t, int64(0), m.lag.Value())
+
+	m.onLatest(20)
+	assert.Equal(t, int64(11), m.lag.Value())
+
+	m.onPolled(21)
+	assert.Equal(t, int64(0), m.lag.Value())
+}
+
+func TestPollerMetricsNamespaceFormat(t *testing.T) {
+	// namespace is not regarded as a format string
+	newPollerMetrics(MetricsOption{"100%done"}, "latest", true)
+	assert.NotNil(t, gometrics.DefaultRegistry.Get("sync/100%done/poll/latest/lag"))
+}
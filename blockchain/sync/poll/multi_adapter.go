@@ -0,0 +1,275 @@
+package poll
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Conflux-Chain/go-conflux-util/log"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+type MultiAdapterOption struct {
+	// RequestTimeout is the timeout for each request to the underlying adapter.
+	RequestTimeout time.Duration `default:"5s"`
+
+	// MaxLag is the max number of blocks that an adapter could fall behind the others,
+	// otherwise, it will not be used to poll blockchain data unless all the others failed.
+	MaxLag uint64 `default:"5"`
+
+	// FailureBackoff is the duration to avoid using an adapter after it failed.
+	FailureBackoff time.Duration `default:"3s"`
+}
+
+type multiAdapterEndpoint[T any] struct {
+	adapter   Adapter[T]
+	finalized atomic.Uint64
+	latest    atomic.Uint64
+	failedAt  atomic.Int64 // unix nano of the last failure, 0 indicates healthy
+}
+
+var _ Adapter[any] = (*MultiAdapter[any])(nil)
+var _ Subscriber = (*MultiAdapter[any])(nil)
+var _ RangeAdapter[any] = (*MultiAdapter[any])(nil)
+
+// MultiAdapter implements the Adapter[T] interface to poll blockchain data from multiple underlying adapters,
+// e.g. multiple fullnodes.
+//
+// It routes requests across the underlying adapters in round-robin, fails over to the others on errors or timeouts,
+// and avoids adapters that fell behind the others.
+//
+// Besides, it implements the Subscriber and RangeAdapter[T] interfaces if the underlying adapters implement them.
+type MultiAdapter[T any] struct {
+	option    MultiAdapterOption
+	endpoints []*multiAdapterEndpoint[T]
+	next      atomic.Uint64 // round-robin index
+}
+
+func NewMultiAdapter[T any](adapters []Adapter[T], option ...MultiAdapterOption) (*MultiAdapter[T], error) {
+	if len(adapters) == 0 {
+		return nil, errors.New("No adapter specified")
+	}
+
+	endpoints := make([]*multiAdapterEndpoint[T], 0, len(adapters))
+	for _, v := range adapters {
+		endpoints = append(endpoints, &multiAdapterEndpoint[T]{adapter: v})
+	}
+
+	return &MultiAdapter[T]{
+		option:    normalizeOpt(option...),
+		endpoints: endpoints,
+	}, nil
+}
+
+// GetFinalizedBlockNumber implements the Adapter[T] interface.
+//
+// It returns the minimum finalized block number of adapters that do not fall behind.
+func (adapter *MultiAdapter[T]) GetFinalizedBlockNumber(ctx context.Context) (uint64, error) {
+	return adapter.queryBlockNumber(ctx, "finalized", func(ctx context.Context, endpoint *multiAdapterEndpoint[T]) (uint64, error) {
+		bn, err := endpoint.adapter.GetFinalizedBlockNumber(ctx)
+		if err == nil {
+			endpoint.finalized.Store(bn)
+		}
+
+		return bn, err
+	})
+}
+
+// GetLatestBlockNumber implements the Adapter[T] interface.
+//
+// It returns the minimum latest block number of adapters that do not fall behind.
+func (adapter *MultiAdapter[T]) GetLatestBlockNumber(ctx context.Context) (uint64, error) {
+	return adapter.queryBlockNumber(ctx, "latest", func(ctx context.Context, endpoint *multiAdapterEndpoint[T]) (uint64, error) {
+		bn, err := endpoint.adapter.GetLatestBlockNumber(ctx)
+		if err == nil {
+			endpoint.latest.Store(bn)
+		}
+
+		return bn, err
+	})
+}
+
+// queryBlockNumber queries block number from all adapters concurrently.
+func (adapter *MultiAdapter[T]) queryBlockNumber(
+	ctx context.Context, kind string, query func(context.Context, *multiAdapterEndpoint[T]) (uint64, error),
+) (uint64, error) {
+	numbers := make([]uint64, len(adapter.endpoints))
+	errs := make([]error, len(adapter.endpoints))
+
+	var wg sync.WaitGroup
+
+	for i, v := range adapter.endpoints {
+		wg.Add(1)
+
+		go func(i int, endpoint *multiAdapterEndpoint[T]) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(ctx, adapter.option.RequestTimeout)
+			defer cancel()
+
+			numbers[i], errs[i] = query(ctx, endpoint)
+			adapter.onResult(i, errs[i])
+
+			if errs[i] != nil {
+				log.WithModule(ModuleName).WithError(errs[i]).WithField("adapter", i).Debugf("Failed to get %v block number in multi adapter", kind)
+			}
+		}(i, v)
+	}
+
+	wg.Wait()
+
+	var highest uint64
+	var lastErr error
+	var succeeded bool
+
+	for i, err := range errs {
+		if err != nil {
+			lastErr = err
+		} else {
+			highest = max(highest, numbers[i])
+			succeeded = true
+		}
+	}
+
+	if !succeeded {
+		return 0, errors.WithMessagef(lastErr, "Failed to get %v block number from all adapters", kind)
+	}
+
+	// the minimum block number of adapters that do not fall behind
+	result := highest
+	for i, err := range errs {
+		if err == nil && numbers[i]+adapter.option.MaxLag >= highest {
+			result = min(result, numbers[i])
+		}
+	}
+
+	return result, nil
+}
+
+func (adapter *MultiAdapter[T]) onResult(index int, err error) {
+	endpoint := adapter.endpoints[index]
+
+	if err == nil {
+		endpoint.failedAt.Store(0)
+		return
+	}
+
+	endpoint.failedAt.Store(time.Now().UnixNano())
+}
+
+// isAvailable returns whether the given endpoint is healthy and not fell behind the others.
+func (adapter *MultiAdapter[T]) isAvailable(endpoint *multiAdapterEndpoint[T], now time.Time) bool {
+	if failedAt := endpoint.failedAt.Load(); failedAt > 0 && now.Sub(time.Unix(0, failedAt)) < adapter.option.FailureBackoff {
+		return false
+	}
+
+	var highestFinalized, highestLatest uint64
+	for _, v := range adapter.endpoints {
+		highestFinalized = max(highestFinalized, v.finalized.Load())
+		highestLatest = max(highestLatest, v.latest.Load())
+	}
+
+	return endpoint.finalized.Load()+adapter.option.MaxLag >= highestFinalized &&
+		endpoint.latest.Load()+adapter.option.MaxLag >= highestLatest
+}
+
+// GetBlockData implements the Adapter[T] interface.
+//
+// It tries the available adapters in round-robin at first, and then the others that failed recently
+// or fell behind.
+func (adapter *MultiAdapter[T]) GetBlockData(ctx context.Context, blockNumber uint64) (T, error) {
+	fields := logrus.Fields{"block": blockNumber}
+
+	return failover(ctx, adapter, fields, func(ctx context.Context, a Adapter[T]) (T, error) {
+		return a.GetBlockData(ctx, blockNumber)
+	})
+}
+
+// GetBlockDataRange implements the RangeAdapter[T] interface in the same way of GetBlockData.
+//
+// Note, the underlying adapter that does not implement the RangeAdapter[T] interface will poll blockchain data
+// block by block.
+func (adapter *MultiAdapter[T]) GetBlockDataRange(ctx context.Context, from, to uint64) ([]T, error) {
+	fields := logrus.Fields{"from": from, "to": to}
+
+	return failover(ctx, adapter, fields, func(ctx context.Context, a Adapter[T]) ([]T, error) {
+		return getBlockDataRange(ctx, a, from, to)
+	})
+}
+
+// SubscribeNewBlocks implements the Subscriber interface, which notifies once any underlying adapter notified.
+//
+// Note, it returns error if none of the underlying adapters implements the Subscriber interface.
+func (adapter *MultiAdapter[T]) SubscribeNewBlocks(ctx context.Context) (<-chan struct{}, error) {
+	adapters := make([]Adapter[T], 0, len(adapter.endpoints))
+	for _, v := range adapter.endpoints {
+		adapters = append(adapters, v.adapter)
+	}
+
+	return subscribeAny(ctx, adapters)
+}
+
+// failover queries the available adapters in round-robin at first, and then the others that failed recently
+// or fell behind, until succeeded.
+func failover[T, V any](
+	ctx context.Context, adapter *MultiAdapter[T], fields logrus.Fields, query func(context.Context, Adapter[T]) (V, error),
+) (value V, err error) {
+	now := time.Now()
+
+	var preferred, others []int
+
+	for i, v := range adapter.endpoints {
+		if adapter.isAvailable(v, now) {
+			preferred = append(preferred, i)
+		} else {
+			others = append(others, i)
+		}
+	}
+
+	// round-robin among available adapters
+	if numPreferred := len(preferred); numPreferred > 1 {
+		start := int(adapter.next.Add(1) % uint64(numPreferred))
+		preferred = append(preferred[start:], preferred[:start]...)
+	}
+
+	for _, index := range append(preferred, others...) {
+		if value, err = queryEndpoint(ctx, adapter, index, query); err == nil {
+			return value, nil
+		}
+
+		// return if context done
+		if ctx.Err() != nil {
+			return value, err
+		}
+
+		log.WithModule(ModuleName).WithError(err).WithFields(fields).WithField("adapter", index).
+			Debug("Failed to get block data in multi adapter, try the next one")
+	}
+
+	return value, errors.WithMessage(err, "Failed to get block data from all adapters")
+}
+
+// queryEndpoint queries the underlying adapter of given index with request timeout, and updates its health state.
+func queryEndpoint[T, V any](
+	ctx context.Context, adapter *MultiAdapter[T], index int, query func(context.Context, Adapter[T]) (V, error),
+) (V, error) {
+	ctx, cancel := context.WithTimeout(ctx, adapter.option.RequestTimeout)
+	defer cancel()
+
+	value, err := query(ctx, adapter.endpoints[index].adapter)
+	adapter.onResult(index, err)
+
+	return value, err
+}
+
+// GetBlockHash implements the Adapter[T] interface.
+func (adapter *MultiAdapter[T]) GetBlockHash(data T) string {
+	return adapter.endpoints[0].adapter.GetBlockHash(data)
+}
+
+// GetParentBlockHash implements the Adapter[T] interface.
+func (adapter *MultiAdapter[T]) GetParentBlockHash(data T) string {
+	return adapter.endpoints[0].adapter.GetParentBlockHash(data)
+}
@@ -0,0 +1,217 @@
+package poll
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/Conflux-Chain/go-conflux-util/blockchain/sync/poll/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+type multiTestAdapter struct {
+	name      string
+	finalized uint64
+	latest    uint64
+	failed    bool
+	requests  int
+}
+
+func (adapter *multiTestAdapter) GetFinalizedBlockNumber(ctx context.Context) (uint64, error) {
+	if adapter.failed {
+		return 0, errors.New("failed")
+	}
+
+	return adapter.finalized, nil
+}
+
+func (adapter *multiTestAdapter) GetLatestBlockNumber(ctx context.Context) (uint64, error) {
+	if adapter.failed {
+		return 0, errors.New("failed")
+	}
+
+	return adapter.latest, nil
+}
+
+func (adapter *multiTestAdapter) GetBlockData(ctx context.Context, blockNumber uint64) (testutil.Data, error) {
+	adapter.requests++
+
+	if adapter.failed {
+		return testutil.Data{}, errors.New("failed")
+	}
+
+	return testutil.Data{
+		Number: blockNumber,
+		Hash:   fmt.Sprintf("%v-%v", adapter.name, blockNumber),
+	}, nil
+}
+
+func (adapter *multiTestAdapter) GetBlockHash(data testutil.Data) string {
+	return data.Hash
+}
+
+func (adapter *multiTestAdapter) GetParentBlockHash(data testutil.Data) string {
+	return data.ParentHash
+}
+
+func TestMultiAdapterBlockNumber(t *testing.T) {
+	a := &multiTestAdapter{name: "a", finalized: 100, latest: 110}
+	b := &multiTestAdapter{name: "b", finalized: 98, latest: 108}
+	c := &multiTestAdapter{name: "c", finalized: 50, latest: 60} // fell behind
+
+	adapter, err := NewMultiAdapter([]Adapter[testutil.Data]{a, b, c}, MultiAdapterOption{MaxLag: 5})
+	assert.NoError(t, err)
+
+	finalized, err := adapter.GetFinalizedBlockNumber(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(98), finalized)
+
+	latest, err := adapter.GetLatestBlockNumber(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(108), latest)
+
+	// some adapters failed
+	b.failed = true
+	finalized, err = adapter.GetFinalizedBlockNumber(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(100), finalized)
+
+	// all adapters failed
+	a.failed = true
+	c.failed = true
+	_, err = adapter.GetFinalizedBlockNumber(context.Background())
+	assert.Error(t, err)
+}
+
+func TestMultiAdapterGetBlockData(t *testing.T) {
+	a := &multiTestAdapter{name: "a", finalized: 100, latest: 110}
+	b := &multiTestAdapter{name: "b", finalized: 100, latest: 110}
+	c := &multiTestAdapter{name: "c", finalized: 50, latest: 60} // fell behind
+
+	adapter, err := NewMultiAdapter([]Adapter[testutil.Data]{a, b, c}, MultiAdapterOption{MaxLag: 5})
+	assert.NoError(t, err)
+
+	_, err = adapter.GetLatestBlockNumber(context.Background())
+	assert.NoError(t, err)
+
+	// round-robin between a and b
+	for i := uint64(0); i < 10; i++ {
+		_, err := adapter.GetBlockData(context.Background(), i)
+		assert.NoError(t, err)
+	}
+
+	assert.Equal(t, 5, a.requests)
+	assert.Equal(t, 5, b.requests)
+	assert.Equal(t, 0, c.requests)
+
+	// fail over to b
+	a.failed = true
+	for i := uint64(0); i < 10; i++ {
+		data, err := adapter.GetBlockData(context.Background(), i)
+		assert.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("b-%v", i), data.Hash)
+	}
+
+	// fail over to c which fell behind
+	b.failed = true
+	data, err := adapter.GetBlockData(context.Background(), 20)
+	assert.NoError(t, err)
+	assert.Equal(t, "c-20", data.Hash)
+
+	// all adapters failed
+	c.failed = true
+	_, err = adapter.GetBlockData(context.Background(), 20)
+	assert.Error(t, err)
+}
+
+type multiTestRangeAdapter struct {
+	*multiTestAdapter
+	rangeRequests int
+}
+
+func (adapter *multiTestRangeAdapter) GetBlockDataRange(ctx context.Context, from, to uint64) ([]testutil.Data, error) {
+	adapter.rangeRequests++
+
+	if adapter.failed {
+		return nil, errors.New("failed")
+	}
+
+	var result []testutil.Data
+	for bn := from; bn <= to; bn++ {
+		result = append(result, testutil.Data{Number: bn, Hash: fmt.Sprintf("%v-%v", adapter.name, bn)})
+	}
+
+	return result, nil
+}
+
+func TestMultiAdapterGetBlockDataRange(t *testing.T) {
+	a := &multiTestRangeAdapter{multiTestAdapter: &multiTestAdapter{name: "a", finalized: 100, latest: 110}}
+	b := &multiTestAdapter{name: "b", finalized: 100, latest: 110}
+
+	adapter, err := NewMultiAdapter([]Adapter[testutil.Data]{a, b})
+	assert.NoError(t, err)
+
+	// round-robin between a and b, where b polls block by block
+	for i := uint64(0); i < 4; i++ {
+		data, err := adapter.GetBlockDataRange(context.Background(), i*10, i*10+4)
+		assert.NoError(t, err)
+		assert.Equal(t, 5, len(data))
+		assert.Equal(t, i*10+4, data[4].Number)
+	}
+
+	assert.Equal(t, 2, a.rangeRequests)
+	assert.Equal(t, 0, a.requests)
+	assert.Equal(t, 10, b.requests)
+
+	// fail over to b
+	a.failed = true
+	data, err := adapter.GetBlockDataRange(context.Background(), 50, 51)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"b-50", "b-51"}, []string{data[0].Hash, data[1].Hash})
+}
+
+type multiTestSubscriber struct {
+	*multiTestAdapter
+	ch chan struct{}
+}
+
+func (adapter *multiTestSubscriber) SubscribeNewBlocks(ctx context.Context) (<-chan struct{}, error) {
+	if adapter.failed {
+		return nil, errors.New("failed")
+	}
+
+	return adapter.ch, nil
+}
+
+func TestMultiAdapterSubscribeNewBlocks(t *testing.T) {
+	a := &multiTestSubscriber{&multiTestAdapter{name: "a"}, make(chan struct{})}
+	b := &multiTestSubscriber{&multiTestAdapter{name: "b", failed: true}, make(chan struct{})}
+	c := &multiTestAdapter{name: "c"}
+
+	adapter, err := NewMultiAdapter([]Adapter[testutil.Data]{a, b, c})
+	assert.NoError(t, err)
+
+	notifyCh, err := adapter.SubscribeNewBlocks(context.Background())
+	assert.NoError(t, err)
+
+	a.ch <- struct{}{}
+	_, ok := <-notifyCh
+	assert.True(t, ok)
+
+	// closed once subscription dropped
+	close(a.ch)
+	_, ok = <-notifyCh
+	assert.False(t, ok)
+
+	// all subscriptions failed
+	a.failed = true
+	_, err = adapter.SubscribeNewBlocks(context.Background())
+	assert.Error(t, err)
+
+	// not supported
+	adapter, err = NewMultiAdapter([]Adapter[testutil.Data]{c})
+	assert.NoError(t, err)
+	_, err = adapter.SubscribeNewBlocks(context.Background())
+	assert.Error(t, err)
+}
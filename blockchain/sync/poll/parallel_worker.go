@@ -2,13 +2,17 @@ package poll
 
 import (
 	"context"
+	"runtime"
 	"sync/atomic"
 	"time"
 
 	"github.com/Conflux-Chain/go-conflux-util/ctxutil"
 	"github.com/Conflux-Chain/go-conflux-util/health"
 	"github.com/Conflux-Chain/go-conflux-util/log"
+	"github.com/Conflux-Chain/go-conflux-util/metrics"
 	"github.com/Conflux-Chain/go-conflux-util/parallel"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 )
 
 type ParallelOption struct {
@@ -16,7 +20,48 @@ type ParallelOption struct {
 
 	RetryInterval time.Duration `default:"1s"`
 
+	// Adaptive enables to tune the number of active routines at runtime, and Routines will be
+	// regarded as the max number of active routines.
+	Adaptive parallel.AdaptiveOption
+
 	Health health.TimedCounterConfig
+
+	Metrics MetricsOption
+}
+
+// newAdaptiveLimiter returns a new adaptive limiter if enabled, otherwise nil.
+func newAdaptiveLimiter(option ParallelOption) *parallel.AdaptiveLimiter {
+	if !option.Adaptive.Enabled {
+		return nil
+	}
+
+	maxRoutines := option.Routines
+	if maxRoutines <= 0 {
+		maxRoutines = runtime.GOMAXPROCS(0)
+	}
+
+	return parallel.NewAdaptiveLimiter(maxRoutines, option.Adaptive, func(limit int) {
+		metrics.GetOrRegisterGauge("%v", option.Metrics.Name("poll/catchup/concurrency")).Update(int64(limit))
+	})
+}
+
+// doLimited executes the given function once acquired from limiter if any, and tunes the limit
+// with the execution latency and error.
+func doLimited[V any](ctx context.Context, limiter *parallel.AdaptiveLimiter, fn func() (V, error)) (V, error) {
+	if limiter == nil {
+		return fn()
+	}
+
+	if err := limiter.Acquire(ctx); err != nil {
+		var zero V
+		return zero, err
+	}
+
+	start := time.Now()
+	result, err := fn()
+	limiter.Release(time.Since(start), err)
+
+	return result, err
 }
 
 // ParallelWorker is used to poll blockchain data in parallel.
@@ -27,17 +72,30 @@ type ParallelWorker[T any] struct {
 	dataCh  chan<- T
 	polled  atomic.Uint64
 	health  *health.TimedCounter
+	limiter *parallel.AdaptiveLimiter // nil if adaptive concurrency disabled
+	metrics *pollerMetrics
 }
 
 func NewParallelWorker[T any](adapter Adapter[T], offset uint64, dataCh chan<- T, option ...ParallelOption) *ParallelWorker[T] {
 	opt := normalizeOpt(option...)
 
+	return newParallelWorker(adapter, offset, dataCh, opt, newAdaptiveLimiter(opt), newPollerMetrics(opt.Metrics, "catchup"))
+}
+
+// newParallelWorker creates a worker with the given limiter and metrics, which are shared across workers of
+// catch up poller.
+func newParallelWorker[T any](
+	adapter Adapter[T], offset uint64, dataCh chan<- T, option ParallelOption,
+	limiter *parallel.AdaptiveLimiter, metrics *pollerMetrics,
+) *ParallelWorker[T] {
 	return &ParallelWorker[T]{
-		option:  opt,
+		option:  option,
 		adapter: adapter,
 		offset:  offset,
 		dataCh:  dataCh,
-		health:  health.NewTimedCounter(opt.Health),
+		health:  health.NewTimedCounter(option.Health),
+		limiter: limiter,
+		metrics: metrics,
 	}
 }
 
@@ -46,7 +104,9 @@ func (worker *ParallelWorker[T]) ParallelDo(ctx context.Context, routine, task i
 	bn := worker.offset + uint64(task)
 
 	for {
-		data, err = worker.adapter.GetBlockData(ctx, bn)
+		data, err = doLimited(ctx, worker.limiter, func() (T, error) {
+			return timedGetBlockData(ctx, worker.adapter, bn, worker.metrics)
+		})
 
 		worker.health.LogOnError(err, "Poll blockchain data in parallel")
 
@@ -75,6 +135,7 @@ func (worker *ParallelWorker[T]) ParallelCollect(ctx context.Context, result *pa
 	worker.polled.Add(1)
 
 	bn := worker.offset + uint64(result.Task)
+	worker.metrics.onPolled(bn + 1)
 	log.WithModule(ModuleName).WithField("block", bn).Trace("Succeeded to collect data in parallel")
 
 	return nil
@@ -84,3 +145,115 @@ func (worker *ParallelWorker[T]) ParallelCollect(ctx context.Context, result *pa
 func (worker *ParallelWorker[T]) Polled() uint64 {
 	return worker.polled.Load()
 }
+
+// RangeParallelWorker is used to poll blockchain data in parallel, and each task polls a range of blocks in batch.
+type RangeParallelWorker[T any] struct {
+	option    ParallelOption
+	adapter   RangeAdapter[T]
+	offset    uint64 // block number offset to poll blockchain data
+	end       uint64 // the last block number to poll blockchain data
+	rangeSize uint64 // number of blocks to poll for each task
+	dataCh    chan<- T
+	polled    atomic.Uint64
+	health    *health.TimedCounter
+	limiter   *parallel.AdaptiveLimiter // nil if adaptive concurrency disabled
+	metrics   *pollerMetrics
+}
+
+func NewRangeParallelWorker[T any](
+	adapter RangeAdapter[T], offset, end, rangeSize uint64, dataCh chan<- T, option ...ParallelOption,
+) *RangeParallelWorker[T] {
+	opt := normalizeOpt(option...)
+
+	return newRangeParallelWorker(adapter, offset, end, rangeSize, dataCh, opt,
+		newAdaptiveLimiter(opt), newPollerMetrics(opt.Metrics, "catchup"))
+}
+
+// newRangeParallelWorker creates a worker with the given limiter and metrics, which are shared across workers of
+// catch up poller.
+func newRangeParallelWorker[T any](
+	adapter RangeAdapter[T], offset, end, rangeSize uint64, dataCh chan<- T, option ParallelOption,
+	limiter *parallel.AdaptiveLimiter, metrics *pollerMetrics,
+) *RangeParallelWorker[T] {
+	return &RangeParallelWorker[T]{
+		option:    option,
+		adapter:   adapter,
+		offset:    offset,
+		end:       end,
+		rangeSize: max(rangeSize, 1),
+		dataCh:    dataCh,
+		health:    health.NewTimedCounter(option.Health),
+		limiter:   limiter,
+		metrics:   metrics,
+	}
+}
+
+// taskRange returns the block range [from, to] of the given task.
+func (worker *RangeParallelWorker[T]) taskRange(task int) (uint64, uint64) {
+	from := worker.offset + uint64(task)*worker.rangeSize
+	to := min(from+worker.rangeSize-1, worker.end)
+
+	return from, to
+}
+
+// ParallelDo implements the parallel.Interface[T] interface.
+func (worker *RangeParallelWorker[T]) ParallelDo(ctx context.Context, routine, task int) (data []T, err error) {
+	from, to := worker.taskRange(task)
+
+	for {
+		data, err = doLimited(ctx, worker.limiter, func() ([]T, error) {
+			start := time.Now()
+			defer worker.metrics.rpc.UpdateSince(start)
+
+			return worker.adapter.GetBlockDataRange(ctx, from, to)
+		})
+		if err == nil && uint64(len(data)) != to-from+1 {
+			err = errors.Errorf("Invalid number of blockchain data, expected = %v, actual = %v", to-from+1, len(data))
+		}
+
+		worker.health.LogOnError(err, "Poll blockchain data range in parallel")
+
+		if err == nil {
+			return data, nil
+		}
+
+		log.WithModule(ModuleName).WithError(err).WithFields(logrus.Fields{
+			"from": from,
+			"to":   to,
+		}).Debug("Failed to poll data range in parallel")
+
+		if err = ctxutil.Sleep(ctx, worker.option.RetryInterval); err != nil {
+			return data, err
+		}
+	}
+}
+
+// ParallelCollect implements the parallel.Interface[T] interface.
+func (worker *RangeParallelWorker[T]) ParallelCollect(ctx context.Context, result *parallel.Result[[]T]) error {
+	if result.Err != nil {
+		return result.Err
+	}
+
+	from, to := worker.taskRange(result.Task)
+
+	for i, v := range result.Value {
+		if err := ctxutil.WriteChannel(ctx, worker.dataCh, v); err != nil {
+			return err
+		}
+
+		worker.polled.Add(1)
+		worker.metrics.onPolled(from + uint64(i) + 1)
+	}
+
+	log.WithModule(ModuleName).WithFields(logrus.Fields{
+		"from": from,
+		"to":   to,
+	}).Trace("Succeeded to collect data range in parallel")
+
+	return nil
+}
+
+// Polled returns the number of actual polled data.
+func (worker *RangeParallelWorker[T]) Polled() uint64 {
+	return worker.polled.Load()
+}
@@ -0,0 +1,288 @@
+package poll
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Conflux-Chain/go-conflux-util/log"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+type QuorumAdapterOption struct {
+	// Quorum is the minimum number of adapters that agree on the same data.
+	//
+	// By default, 0 indicates the majority of adapters.
+	Quorum int
+
+	// RequestTimeout is the timeout for each request to the underlying adapter.
+	RequestTimeout time.Duration `default:"5s"`
+}
+
+type QuorumAdapterParams[T any] struct {
+	Adapters []Adapter[T]
+
+	// optional, returns the digest of payload to compare besides the block hash and parent block hash
+	Digest func(data T) string
+
+	// optional, handles disagreements among adapters, by default, a warning log will be printed
+	OnDisagreement func(disagreement Disagreement)
+}
+
+// Disagreement represents the inconsistent blockchain data polled from multiple adapters.
+type Disagreement struct {
+	BlockNumber uint64
+	Votes       map[string][]int // data key (block hash, parent block hash and digest) => adapter indices
+	Errors      map[int]error    // adapter index => error
+	Agreed      bool             // whether quorum reached
+}
+
+func (d Disagreement) String() string {
+	return fmt.Sprintf("{ block = %v, votes = %v, errors = %v, agreed = %v }", d.BlockNumber, d.Votes, len(d.Errors), d.Agreed)
+}
+
+var _ Adapter[any] = (*QuorumAdapter[any])(nil)
+var _ Subscriber = (*QuorumAdapter[any])(nil)
+var _ RangeAdapter[any] = (*QuorumAdapter[any])(nil)
+
+// QuorumAdapter implements the Adapter[T] interface to poll the same blockchain data from multiple adapters,
+// and accepts the data only when a quorum of adapters agree on it.
+//
+// Besides, it implements the Subscriber and RangeAdapter[T] interfaces if the underlying adapters implement them.
+type QuorumAdapter[T any] struct {
+	option         QuorumAdapterOption
+	adapters       []Adapter[T]
+	digest         func(data T) string
+	onDisagreement func(disagreement Disagreement)
+}
+
+func NewQuorumAdapter[T any](params QuorumAdapterParams[T], option ...QuorumAdapterOption) (*QuorumAdapter[T], error) {
+	numAdapters := len(params.Adapters)
+	if numAdapters == 0 {
+		return nil, errors.New("No adapter specified")
+	}
+
+	opt := normalizeOpt(option...)
+
+	if opt.Quorum <= 0 {
+		opt.Quorum = numAdapters/2 + 1
+	}
+
+	if opt.Quorum > numAdapters {
+		return nil, errors.Errorf("Quorum %v is greater than the number of adapters %v", opt.Quorum, numAdapters)
+	}
+
+	adapter := QuorumAdapter[T]{
+		option:         opt,
+		adapters:       params.Adapters,
+		digest:         params.Digest,
+		onDisagreement: params.OnDisagreement,
+	}
+
+	if adapter.onDisagreement == nil {
+		adapter.onDisagreement = func(disagreement Disagreement) {
+			log.WithModule(ModuleName).WithField("disagreement", disagreement).Warn("Blockchain data disagreed among adapters")
+		}
+	}
+
+	return &adapter, nil
+}
+
+// GetFinalizedBlockNumber implements the Adapter[T] interface.
+//
+// It returns the highest finalized block number that a quorum of adapters have reached.
+func (adapter *QuorumAdapter[T]) GetFinalizedBlockNumber(ctx context.Context) (uint64, error) {
+	return adapter.queryBlockNumber(ctx, "finalized", func(ctx context.Context, a Adapter[T]) (uint64, error) {
+		return a.GetFinalizedBlockNumber(ctx)
+	})
+}
+
+// GetLatestBlockNumber implements the Adapter[T] interface.
+//
+// It returns the highest latest block number that a quorum of adapters have reached.
+func (adapter *QuorumAdapter[T]) GetLatestBlockNumber(ctx context.Context) (uint64, error) {
+	return adapter.queryBlockNumber(ctx, "latest", func(ctx context.Context, a Adapter[T]) (uint64, error) {
+		return a.GetLatestBlockNumber(ctx)
+	})
+}
+
+func (adapter *QuorumAdapter[T]) queryBlockNumber(
+	ctx context.Context, kind string, query func(context.Context, Adapter[T]) (uint64, error),
+) (uint64, error) {
+	numbers, errs := queryAll(ctx, adapter, query)
+
+	var succeeded []uint64
+	var lastErr error
+
+	for i, err := range errs {
+		if err == nil {
+			succeeded = append(succeeded, numbers[i])
+		} else {
+			lastErr = err
+		}
+	}
+
+	if len(succeeded) < adapter.option.Quorum {
+		return 0, errors.WithMessagef(lastErr, "Failed to get %v block number from quorum adapters", kind)
+	}
+
+	// in descending order
+	slices.Sort(succeeded)
+	slices.Reverse(succeeded)
+
+	return succeeded[adapter.option.Quorum-1], nil
+}
+
+// GetBlockData implements the Adapter[T] interface.
+//
+// It returns error if a quorum of adapters do not agree on the same data.
+func (adapter *QuorumAdapter[T]) GetBlockData(ctx context.Context, blockNumber uint64) (T, error) {
+	values, errs := queryAll(ctx, adapter, func(ctx context.Context, a Adapter[T]) (T, error) {
+		return a.GetBlockData(ctx, blockNumber)
+	})
+
+	return adapter.vote(blockNumber, values, errs)
+}
+
+// GetBlockDataRange implements the RangeAdapter[T] interface, and votes for blockchain data block by block.
+//
+// Note, the underlying adapter that does not implement the RangeAdapter[T] interface will poll blockchain data
+// block by block.
+func (adapter *QuorumAdapter[T]) GetBlockDataRange(ctx context.Context, from, to uint64) ([]T, error) {
+	if from > to {
+		return nil, errors.Errorf("Invalid block range [%v, %v]", from, to)
+	}
+
+	ranges, rangeErrs := queryAll(ctx, adapter, func(ctx context.Context, a Adapter[T]) ([]T, error) {
+		data, err := getBlockDataRange(ctx, a, from, to)
+		if err == nil && uint64(len(data)) != to-from+1 {
+			err = errors.Errorf("Invalid number of blockchain data, expected = %v, actual = %v", to-from+1, len(data))
+		}
+
+		return data, err
+	})
+
+	result := make([]T, 0, to-from+1)
+	values := make([]T, len(ranges))
+
+	for i := range to - from + 1 {
+		for j, v := range ranges {
+			if rangeErrs[j] == nil {
+				values[j] = v[i]
+			}
+		}
+
+		data, err := adapter.vote(from+i, values, rangeErrs)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, data)
+	}
+
+	return result, nil
+}
+
+// SubscribeNewBlocks implements the Subscriber interface, which notifies once any underlying adapter notified.
+//
+// Note, it returns error if none of the underlying adapters implements the Subscriber interface.
+func (adapter *QuorumAdapter[T]) SubscribeNewBlocks(ctx context.Context) (<-chan struct{}, error) {
+	return subscribeAny(ctx, adapter.adapters)
+}
+
+// vote returns the blockchain data that a quorum of adapters agree on.
+func (adapter *QuorumAdapter[T]) vote(blockNumber uint64, values []T, errs []error) (data T, err error) {
+	disagreement := Disagreement{
+		BlockNumber: blockNumber,
+		Votes:       make(map[string][]int),
+		Errors:      make(map[int]error),
+	}
+
+	var winner string
+	var lastErr error
+
+	for i, err := range errs {
+		if err != nil {
+			disagreement.Errors[i] = err
+			lastErr = err
+			continue
+		}
+
+		key := adapter.key(values[i])
+		disagreement.Votes[key] = append(disagreement.Votes[key], i)
+
+		if len(disagreement.Votes[key]) > len(disagreement.Votes[winner]) {
+			winner = key
+		}
+	}
+
+	votes := disagreement.Votes[winner]
+	disagreement.Agreed = len(votes) >= adapter.option.Quorum
+
+	if len(disagreement.Votes) > 1 {
+		adapter.onDisagreement(disagreement)
+	}
+
+	if disagreement.Agreed {
+		return values[votes[0]], nil
+	}
+
+	log.WithModule(ModuleName).WithFields(logrus.Fields{
+		"block":        blockNumber,
+		"disagreement": disagreement,
+	}).Debug("Failed to reach quorum for blockchain data")
+
+	if lastErr != nil {
+		return data, errors.WithMessage(lastErr, "Failed to reach quorum for blockchain data")
+	}
+
+	return data, errors.Errorf("Failed to reach quorum for blockchain data, votes = %v", len(votes))
+}
+
+func (adapter *QuorumAdapter[T]) key(data T) string {
+	key := adapter.GetBlockHash(data) + "/" + adapter.GetParentBlockHash(data)
+
+	if adapter.digest != nil {
+		key += "/" + adapter.digest(data)
+	}
+
+	return key
+}
+
+// GetBlockHash implements the Adapter[T] interface.
+func (adapter *QuorumAdapter[T]) GetBlockHash(data T) string {
+	return adapter.adapters[0].GetBlockHash(data)
+}
+
+// GetParentBlockHash implements the Adapter[T] interface.
+func (adapter *QuorumAdapter[T]) GetParentBlockHash(data T) string {
+	return adapter.adapters[0].GetParentBlockHash(data)
+}
+
+// queryAll queries all adapters concurrently with request timeout.
+func queryAll[T, V any](ctx context.Context, adapter *QuorumAdapter[T], query func(context.Context, Adapter[T]) (V, error)) ([]V, []error) {
+	values := make([]V, len(adapter.adapters))
+	errs := make([]error, len(adapter.adapters))
+
+	var wg sync.WaitGroup
+
+	for i, v := range adapter.adapters {
+		wg.Add(1)
+
+		go func(i int, a Adapter[T]) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(ctx, adapter.option.RequestTimeout)
+			defer cancel()
+
+			values[i], errs[i] = query(ctx, a)
+		}(i, v)
+	}
+
+	wg.Wait()
+
+	return values, errs
+}
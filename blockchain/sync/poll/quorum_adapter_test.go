@@ -0,0 +1,125 @@
+package poll
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Conflux-Chain/go-conflux-util/blockchain/sync/poll/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuorumAdapterBlockNumber(t *testing.T) {
+	a := &multiTestAdapter{name: "a", finalized: 100, latest: 110}
+	b := &multiTestAdapter{name: "b", finalized: 98, latest: 108}
+	c := &multiTestAdapter{name: "c", finalized: 50, latest: 60}
+
+	adapter, err := NewQuorumAdapter(QuorumAdapterParams[testutil.Data]{
+		Adapters: []Adapter[testutil.Data]{a, b, c},
+	})
+	assert.NoError(t, err)
+
+	// 2 of 3 adapters reached
+	finalized, err := adapter.GetFinalizedBlockNumber(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(98), finalized)
+
+	latest, err := adapter.GetLatestBlockNumber(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(108), latest)
+
+	// quorum not reached
+	b.failed = true
+	c.failed = true
+	_, err = adapter.GetFinalizedBlockNumber(context.Background())
+	assert.Error(t, err)
+}
+
+func TestQuorumAdapterGetBlockData(t *testing.T) {
+	a := &multiTestAdapter{name: "x"}
+	b := &multiTestAdapter{name: "x"}
+	c := &multiTestAdapter{name: "y"} // inconsistent data
+
+	var disagreements []Disagreement
+
+	adapter, err := NewQuorumAdapter(QuorumAdapterParams[testutil.Data]{
+		Adapters: []Adapter[testutil.Data]{a, b, c},
+		OnDisagreement: func(disagreement Disagreement) {
+			disagreements = append(disagreements, disagreement)
+		},
+	})
+	assert.NoError(t, err)
+
+	// 2 of 3 agreed
+	data, err := adapter.GetBlockData(context.Background(), 5)
+	assert.NoError(t, err)
+	assert.Equal(t, "x-5", data.Hash)
+	assert.Equal(t, 1, len(disagreements))
+	assert.True(t, disagreements[0].Agreed)
+	assert.Equal(t, map[string][]int{"x-5/": {0, 1}, "y-5/": {2}}, disagreements[0].Votes)
+
+	// quorum not reached
+	b.failed = true
+	_, err = adapter.GetBlockData(context.Background(), 6)
+	assert.Error(t, err)
+	assert.Equal(t, 2, len(disagreements))
+	assert.False(t, disagreements[1].Agreed)
+
+	// all agreed
+	b.failed = false
+	c.name = "x"
+	data, err = adapter.GetBlockData(context.Background(), 7)
+	assert.NoError(t, err)
+	assert.Equal(t, "x-7", data.Hash)
+	assert.Equal(t, 2, len(disagreements))
+}
+
+func TestQuorumAdapterGetBlockDataRange(t *testing.T) {
+	a := &multiTestRangeAdapter{multiTestAdapter: &multiTestAdapter{name: "x"}}
+	b := &multiTestAdapter{name: "x"} // poll block by block
+	c := &multiTestRangeAdapter{multiTestAdapter: &multiTestAdapter{name: "y"}}
+
+	var disagreements []Disagreement
+
+	adapter, err := NewQuorumAdapter(QuorumAdapterParams[testutil.Data]{
+		Adapters: []Adapter[testutil.Data]{a, b, c},
+		OnDisagreement: func(disagreement Disagreement) {
+			disagreements = append(disagreements, disagreement)
+		},
+	})
+	assert.NoError(t, err)
+
+	// 2 of 3 agreed
+	data, err := adapter.GetBlockDataRange(context.Background(), 5, 7)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"x-5", "x-6", "x-7"}, []string{data[0].Hash, data[1].Hash, data[2].Hash})
+	assert.Equal(t, 3, len(disagreements))
+	assert.Equal(t, uint64(7), disagreements[2].BlockNumber)
+	assert.Equal(t, 1, a.rangeRequests)
+	assert.Equal(t, 3, b.requests)
+
+	// quorum not reached
+	a.failed = true
+	_, err = adapter.GetBlockDataRange(context.Background(), 8, 9)
+	assert.Error(t, err)
+}
+
+func TestQuorumAdapterSubscribeNewBlocks(t *testing.T) {
+	a := &multiTestSubscriber{&multiTestAdapter{name: "a"}, make(chan struct{})}
+	b := &multiTestAdapter{name: "b"}
+
+	adapter, err := NewQuorumAdapter(QuorumAdapterParams[testutil.Data]{
+		Adapters: []Adapter[testutil.Data]{a, b},
+	})
+	assert.NoError(t, err)
+
+	notifyCh, err := adapter.SubscribeNewBlocks(context.Background())
+	assert.NoError(t, err)
+
+	a.ch <- struct{}{}
+	_, ok := <-notifyCh
+	assert.True(t, ok)
+
+	close(a.ch)
+	_, ok = <-notifyCh
+	assert.False(t, ok)
+}
@@ -0,0 +1,95 @@
+package poll
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Conflux-Chain/go-conflux-util/alert"
+	"github.com/Conflux-Chain/go-conflux-util/log"
+	"github.com/Conflux-Chain/go-conflux-util/metrics"
+	"github.com/sirupsen/logrus"
+)
+
+type ReorgOption struct {
+	// AlertDepth is the min depth of chain reorg to send alert, 0 indicates alert disabled.
+	AlertDepth int
+
+	// AlertChannel is the name of alert channel, which should be configured in alert.DefaultManager().
+	AlertChannel string
+
+	AlertSeverity alert.Severity `default:"2"` // high
+	AlertTimeout  time.Duration  `default:"5s"`
+}
+
+// reorgMonitor tracks the total depth of each chain reorg, from the first popped block until a block
+// appended again, and reports it via metrics and alert.
+type reorgMonitor struct {
+	option  ReorgOption
+	metrics MetricsOption
+	depth   int    // number of popped blocks of the ongoing chain reorg, 0 indicates no reorg
+	latest  uint64 // the latest block number before chain reorg
+}
+
+func newReorgMonitor(option ReorgOption, metricsOption MetricsOption) *reorgMonitor {
+	return &reorgMonitor{option: option, metrics: metricsOption}
+}
+
+// onPopped should be called once the given block popped from reorg window.
+func (monitor *reorgMonitor) onPopped(blockNumber uint64) {
+	if monitor.depth == 0 {
+		monitor.latest = blockNumber
+	}
+
+	monitor.depth++
+}
+
+// onAppended should be called once the given block appended to reorg window.
+func (monitor *reorgMonitor) onAppended(blockNumber uint64) {
+	if monitor.depth == 0 {
+		return
+	}
+
+	depth := monitor.depth
+	monitor.depth = 0
+
+	metrics.GetOrRegisterCounter("%v", monitor.metrics.Name("poll/latest/reorg")).Inc(1)
+	metrics.GetOrRegisterHistogram("%v", monitor.metrics.Name("poll/latest/reorg/depth")).Update(int64(depth))
+
+	logger := log.WithModule(ModuleName).WithFields(logrus.Fields{
+		"depth":  depth,
+		"latest": monitor.latest,
+		"fork":   blockNumber - 1,
+	})
+
+	if monitor.option.AlertDepth <= 0 || depth < monitor.option.AlertDepth {
+		logger.Debug("Chain reorg completed")
+		return
+	}
+
+	logger.Warn("Deep chain reorg detected")
+
+	go monitor.alert(depth, monitor.latest, blockNumber-1)
+}
+
+func (monitor *reorgMonitor) alert(depth int, latestBlockNumber, forkBlockNumber uint64) {
+	channel, ok := alert.DefaultManager().Channel(monitor.option.AlertChannel)
+	if !ok {
+		log.WithModule(ModuleName).WithField("channel", monitor.option.AlertChannel).Warn("Alert channel not found for chain reorg")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), monitor.option.AlertTimeout)
+	defer cancel()
+
+	err := channel.Send(ctx, &alert.Notification{
+		Title: "Deep chain reorg detected",
+		Content: fmt.Sprintf("%v blocks reverted, latest block = %v, fork block = %v",
+			depth, latestBlockNumber, forkBlockNumber),
+		Severity: monitor.option.AlertSeverity,
+	})
+
+	if err != nil {
+		log.WithModule(ModuleName).WithError(err).Warn("Failed to send alert for chain reorg")
+	}
+}
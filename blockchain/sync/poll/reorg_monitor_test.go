@@ -0,0 +1,51 @@
+package poll
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Conflux-Chain/go-conflux-util/alert"
+	"github.com/stretchr/testify/assert"
+)
+
+type testAlertChannel struct {
+	notifications chan *alert.Notification
+}
+
+func (ch *testAlertChannel) Name() string            { return "sync.poll.test" }
+func (ch *testAlertChannel) Type() alert.ChannelType { return "test" }
+
+func (ch *testAlertChannel) Send(ctx context.Context, note *alert.Notification) error {
+	ch.notifications <- note
+	return nil
+}
+
+func TestReorgMonitor(t *testing.T) {
+	ch := testAlertChannel{make(chan *alert.Notification, 1)}
+	alert.DefaultManager().Add(&ch)
+	defer alert.DefaultManager().Del(ch.Name())
+
+	monitor := newReorgMonitor(normalizeOpt(ReorgOption{AlertDepth: 3, AlertChannel: ch.Name()}), MetricsOption{})
+
+	// shallow reorg
+	monitor.onPopped(10)
+	monitor.onPopped(9)
+	monitor.onAppended(9)
+
+	// deep reorg
+	monitor.onPopped(10)
+	monitor.onPopped(9)
+	monitor.onPopped(8)
+	monitor.onAppended(8)
+
+	select {
+	case note := <-ch.notifications:
+		assert.Equal(t, alert.SeverityHigh, note.Severity)
+		assert.Equal(t, "3 blocks reverted, latest block = 10, fork block = 7", note.Content)
+	case <-time.After(time.Second):
+		assert.Fail(t, "Alert not sent")
+	}
+
+	assert.Empty(t, ch.notifications)
+}
@@ -1,6 +1,18 @@
 package poll
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrBlockNotInSequence is returned when the pushed block is not the next block of the latest one.
+	ErrBlockNotInSequence = errors.New("Block not in sequence")
+
+	// ErrFinalizedBlockReverted is returned when the finalized block is to be reverted, e.g. fullnode reports an
+	// inconsistent finalized block temporarily.
+	ErrFinalizedBlockReverted = errors.New("Finalized block reverted")
+)
 
 type ReorgWindowParams struct {
 	FinalizedBlockNumber uint64
@@ -73,7 +85,7 @@ func (window *ReorgWindow) Push(blockNumber uint64, blockHash, parentBlockHash s
 
 	// not in sequence
 	if window.latest+1 != blockNumber {
-		return false, false, fmt.Errorf("Block not in sequence, latest = %v, new = %v", window.latest, blockNumber)
+		return false, false, fmt.Errorf("%w, latest = %v, new = %v", ErrBlockNotInSequence, window.latest, blockNumber)
 	}
 
 	// appended if parent block hash matches
@@ -86,7 +98,7 @@ func (window *ReorgWindow) Push(blockNumber uint64, blockHash, parentBlockHash s
 
 	// finalized block should never be reverted
 	if window.finalized == window.latest {
-		return false, false, fmt.Errorf("Finalized block %v should not be reverted", window.finalized)
+		return false, false, fmt.Errorf("%w, finalized = %v", ErrFinalizedBlockReverted, window.finalized)
 	}
 
 	// reorg detected, pop the latest block from reorg window
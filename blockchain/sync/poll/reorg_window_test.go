@@ -24,9 +24,9 @@ func TestReorgWindowPush(t *testing.T) {
 
 	// push not in sequence
 	_, _, err = window.Push(6, "Hash - 6", "Hash - 5")
-	assert.Error(t, err)
+	assert.ErrorIs(t, err, ErrBlockNotInSequence)
 	_, _, err = window.Push(8, "Hash - 8", "Hash - 7")
-	assert.Error(t, err)
+	assert.ErrorIs(t, err, ErrBlockNotInSequence)
 
 	// push in sequence, but parent hash mismatch
 	pushed, popped, err = window.Push(7, "Hash - 7", "Hash - 66")
@@ -36,7 +36,7 @@ func TestReorgWindowPush(t *testing.T) {
 
 	// pop the finalized block 5
 	_, _, err = window.Push(6, "Hash - 66", "Hash - 55")
-	assert.Error(t, err)
+	assert.ErrorIs(t, err, ErrFinalizedBlockReverted)
 
 	// push block 6 again
 	pushed, popped, err = window.Push(6, "Hash - 66", "Hash - 5")
@@ -0,0 +1,189 @@
+package poll
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Conflux-Chain/go-conflux-util/ctxutil"
+	"github.com/Conflux-Chain/go-conflux-util/log"
+	"github.com/pkg/errors"
+)
+
+type SubscriptionOption struct {
+	// Enabled indicates to wake up poller once new block arrived, if the adapter implements
+	// the Subscriber interface.
+	Enabled bool
+
+	// Timeout is the max duration to wait for new block notification, in case of any notification lost.
+	Timeout time.Duration `default:"30s"`
+
+	// ResubscribeInterval is the min interval to subscribe again after subscription dropped, and poller
+	// will fall back to poll data at IdleInterval in the meantime.
+	ResubscribeInterval time.Duration `default:"5s"`
+}
+
+// Subscription is implemented by the underlying RPC subscription, e.g. ethereum.Subscription.
+type Subscription interface {
+	Err() <-chan error
+	Unsubscribe()
+}
+
+// NewNotifyChannel converts the given subscription channel to a notification channel, which is closed once
+// subscription dropped or context done. Note, notifications will be coalesced if not consumed in time.
+//
+// It is used by adapters to implement the Subscriber interface.
+func NewNotifyChannel[V any](ctx context.Context, sub Subscription, ch <-chan V) <-chan struct{} {
+	notifyCh := make(chan struct{}, 1)
+
+	go func() {
+		defer close(notifyCh)
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case err := <-sub.Err():
+				log.WithModule(ModuleName).WithError(err).Debug("Subscription dropped")
+				return
+			case <-ch:
+				select {
+				case notifyCh <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+
+	return notifyCh
+}
+
+// subscribeAny subscribes new blocks from all the given adapters that implement the Subscriber interface, and
+// notifies once any adapter notified. The returned channel is closed once any subscription dropped or context done,
+// so that poller could subscribe again later.
+func subscribeAny[T any](ctx context.Context, adapters []Adapter[T]) (<-chan struct{}, error) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	var channels []<-chan struct{}
+	var lastErr error
+
+	for _, v := range adapters {
+		subscriber, ok := v.(Subscriber)
+		if !ok {
+			continue
+		}
+
+		ch, err := subscriber.SubscribeNewBlocks(ctx)
+		if err != nil {
+			lastErr = err
+		} else {
+			channels = append(channels, ch)
+		}
+	}
+
+	if len(channels) == 0 {
+		cancel()
+
+		if lastErr != nil {
+			return nil, errors.WithMessage(lastErr, "Failed to subscribe new blocks from all adapters")
+		}
+
+		return nil, errors.New("No adapter supports subscription")
+	}
+
+	notifyCh := make(chan struct{}, 1)
+
+	var wg sync.WaitGroup
+	for _, v := range channels {
+		wg.Add(1)
+
+		go func(ch <-chan struct{}) {
+			defer wg.Done()
+
+			// unsubscribe the others once any subscription dropped
+			defer cancel()
+
+			for range ch {
+				select {
+				case notifyCh <- struct{}{}:
+				default:
+				}
+			}
+		}(v)
+	}
+
+	go func() {
+		wg.Wait()
+		cancel()
+		close(notifyCh)
+	}()
+
+	return notifyCh, nil
+}
+
+// waiter is used by poller to wait for new blocks, either in push mode via subscription or at idle interval.
+type waiter struct {
+	option       SubscriptionOption
+	idleInterval time.Duration
+	subscriber   Subscriber // nil if subscription disabled
+
+	notifyCh         <-chan struct{} // nil if not subscribed yet or subscription dropped
+	lastSubscribedAt time.Time
+}
+
+func newWaiter(adapter any, option Option) *waiter {
+	w := waiter{
+		option:       option.Subscription,
+		idleInterval: option.IdleInterval,
+	}
+
+	if subscriber, ok := adapter.(Subscriber); ok && option.Subscription.Enabled {
+		w.subscriber = subscriber
+	}
+
+	return &w
+}
+
+// wait waits for new block notification, or falls back to sleep at idle interval if subscription unavailable.
+//
+// It returns error only when context done.
+func (w *waiter) wait(ctx context.Context) error {
+	if w.subscriber != nil && w.notifyCh == nil && time.Since(w.lastSubscribedAt) >= w.option.ResubscribeInterval {
+		w.subscribe(ctx)
+	}
+
+	if w.notifyCh == nil {
+		return ctxutil.Sleep(ctx, w.idleInterval)
+	}
+
+	timer := time.NewTimer(w.option.Timeout)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case _, ok := <-w.notifyCh:
+		if !ok {
+			log.WithModule(ModuleName).Warn("Subscription dropped, fall back to poll at idle interval")
+			w.notifyCh = nil
+		}
+	case <-timer.C:
+	}
+
+	return nil
+}
+
+func (w *waiter) subscribe(ctx context.Context) {
+	w.lastSubscribedAt = time.Now()
+
+	notifyCh, err := w.subscriber.SubscribeNewBlocks(ctx)
+	if err != nil {
+		log.WithModule(ModuleName).WithError(err).Warn("Failed to subscribe new blocks, fall back to poll at idle interval")
+		return
+	}
+
+	log.WithModule(ModuleName).Debug("Succeeded to subscribe new blocks")
+
+	w.notifyCh = notifyCh
+}
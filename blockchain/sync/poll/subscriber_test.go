@@ -0,0 +1,81 @@
+package poll
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testSubscriber struct {
+	notifyCh      chan struct{}
+	subscriptions int
+}
+
+func (s *testSubscriber) SubscribeNewBlocks(ctx context.Context) (<-chan struct{}, error) {
+	s.subscriptions++
+	return s.notifyCh, nil
+}
+
+type testSubscription struct {
+	errCh        chan error
+	unsubscribed bool
+}
+
+func (sub *testSubscription) Err() <-chan error { return sub.errCh }
+func (sub *testSubscription) Unsubscribe()      { sub.unsubscribed = true }
+
+func TestWaiter(t *testing.T) {
+	subscriber := testSubscriber{notifyCh: make(chan struct{}, 1)}
+	w := newWaiter(&subscriber, Option{
+		IdleInterval: 10 * time.Millisecond,
+		Subscription: SubscriptionOption{
+			Enabled:             true,
+			Timeout:             time.Hour,
+			ResubscribeInterval: time.Hour,
+		},
+	})
+
+	// wake up once new block arrived
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		subscriber.notifyCh <- struct{}{}
+	}()
+
+	assert.NoError(t, w.wait(context.Background()))
+	assert.Equal(t, 1, subscriber.subscriptions)
+
+	// fall back to poll at idle interval once subscription dropped
+	close(subscriber.notifyCh)
+	assert.NoError(t, w.wait(context.Background()))
+
+	start := time.Now()
+	assert.NoError(t, w.wait(context.Background()))
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, 1, subscriber.subscriptions)
+
+	// context done
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Error(t, w.wait(ctx))
+}
+
+func TestNewNotifyChannel(t *testing.T) {
+	sub := testSubscription{errCh: make(chan error, 1)}
+	ch := make(chan int)
+
+	notifyCh := NewNotifyChannel(context.Background(), &sub, ch)
+
+	// coalesce notifications
+	ch <- 1
+	ch <- 2
+	_, ok := <-notifyCh
+	assert.True(t, ok)
+
+	// closed once subscription dropped
+	sub.errCh <- context.DeadlineExceeded
+	for range notifyCh {
+	}
+	assert.True(t, sub.unsubscribed)
+}
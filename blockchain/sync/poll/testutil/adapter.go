@@ -17,6 +17,10 @@ func (data Data) Size() int {
 	return size.Of(data)
 }
 
+func (data Data) BlockNumber() uint64 {
+	return data.Number
+}
+
 type Adapter struct {
 	finalized     []uint64
 	nextFinalized int
@@ -0,0 +1,115 @@
+package testutil
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+)
+
+// RPCHandler handles a JSON-RPC request, and returns nil result if not found.
+type RPCHandler func(method string, params []json.RawMessage) (any, error)
+
+type RPCRequest struct {
+	ID     json.RawMessage   `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type rpcResponse struct {
+	Version string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+// RPCServer mocks a JSON-RPC server over HTTP, which responds batch requests in reversed order, so as to
+// verify that responses are matched by request id.
+type RPCServer struct {
+	*httptest.Server
+
+	handler RPCHandler
+
+	mu       sync.Mutex
+	requests []RPCRequest // all handled requests
+}
+
+// NewRPCServer starts a new JSON-RPC server, which should be closed after used.
+func NewRPCServer(handler RPCHandler) *RPCServer {
+	server := RPCServer{handler: handler}
+	server.Server = httptest.NewServer(http.HandlerFunc(server.serveHTTP))
+
+	return &server
+}
+
+func (server *RPCServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	var body json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if len(body) == 0 || body[0] != '[' {
+		var request RPCRequest
+		if err := json.Unmarshal(body, &request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		json.NewEncoder(w).Encode(server.handle(request))
+		return
+	}
+
+	var requests []RPCRequest
+	if err := json.Unmarshal(body, &requests); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var responses []rpcResponse
+	for _, v := range slices.Backward(requests) {
+		responses = append(responses, server.handle(v))
+	}
+
+	json.NewEncoder(w).Encode(responses)
+}
+
+func (server *RPCServer) handle(request RPCRequest) rpcResponse {
+	server.mu.Lock()
+	server.requests = append(server.requests, request)
+	server.mu.Unlock()
+
+	result, err := server.handler(request.Method, request.Params)
+	if err != nil {
+		return rpcResponse{Version: "2.0", ID: request.ID, Error: &rpcError{-32000, err.Error()}}
+	}
+
+	if result == nil {
+		result = json.RawMessage("null")
+	}
+
+	return rpcResponse{Version: "2.0", ID: request.ID, Result: result}
+}
+
+// Requests returns the handled requests of the given method.
+func (server *RPCServer) Requests(method string) []RPCRequest {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	var result []RPCRequest
+	for _, v := range server.requests {
+		if v.Method == method {
+			result = append(result, v)
+		}
+	}
+
+	return result
+}
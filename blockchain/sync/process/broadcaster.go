@@ -0,0 +1,292 @@
+package process
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Conflux-Chain/go-conflux-util/channel"
+	"github.com/Conflux-Chain/go-conflux-util/ctxutil"
+	"github.com/Conflux-Chain/go-conflux-util/health"
+	"github.com/Conflux-Chain/go-conflux-util/log"
+	"github.com/mcuadros/go-defaults"
+	"github.com/sirupsen/logrus"
+)
+
+var ModuleName = "sync.process"
+
+// FallibleProcessor is optionally implemented by Processor[T] to report the processing error, so that
+// Broadcaster could retry according to the retry policy of sink. Otherwise, Processor[T] is regarded as
+// failed if panicked.
+type FallibleProcessor[T any] interface {
+	// TryProcess processes the given data, and returns error if failed.
+	TryProcess(ctx context.Context, data T) error
+}
+
+type SinkOption struct {
+	// Name is used to identify the sink in logs and cursors.
+	Name string
+
+	// Buffer bounds the polled data that not processed by sink yet.
+	Buffer struct {
+		Capacity int `default:"1024"`
+		MaxBytes int `default:"256000000"` // 256M
+	}
+
+	// RetryInterval is the initial interval to retry if processor failed, which doubles
+	// after each failure till MaxRetryInterval.
+	RetryInterval    time.Duration `default:"1s"`
+	MaxRetryInterval time.Duration `default:"1m"`
+
+	Health health.TimedCounterConfig
+}
+
+// Sink is a processor that consumes the broadcasted data independently.
+type Sink[T any] struct {
+	Processor Processor[T]
+	Option    SinkOption
+}
+
+type sink[T channel.Sizable] struct {
+	option    SinkOption
+	processor Processor[T]
+	ch        *channel.MemoryBoundedChannel[T]
+	cursor    atomic.Uint64 // number of processed data
+	health    *health.TimedCounter
+}
+
+type BroadcasterOption[T any] struct {
+	// MaxPending bounds the data not acknowledged by all sinks yet, so that poller will be blocked and not
+	// advance too far away from the slowest sink.
+	MaxPending int `default:"1024"`
+
+	// Checkpoint is optional to handle data once acknowledged by all sinks in sequence, e.g. persist the
+	// sync progress via db.AggregateProcessor with CheckpointProcessor, so that sync task will be resumed
+	// from the slowest sink after service restarted.
+	Checkpoint Processor[T]
+}
+
+type pendingData[T any] struct {
+	data    T
+	pending int // number of sinks that not processed yet
+}
+
+// Broadcaster feeds the polled data to multiple sinks, so that a slow sink will not stall the others
+// unless its own buffer is full.
+//
+// Broadcaster implements the Processor[T] interface, which only returns after data written into the buffer
+// of all sinks, and blocks if too many data not acknowledged by all sinks yet. So, poller will not advance
+// too far away from the slowest sink.
+type Broadcaster[T channel.Sizable] struct {
+	sinks      []*sink[T]
+	window     chan struct{} // bounds the pending data
+	checkpoint Processor[T]  // optional
+
+	mu           sync.Mutex
+	pending      []pendingData[T] // data not acknowledged by all sinks yet in sequence
+	base         uint64           // sequence of the first pending data
+	acknowledged atomic.Uint64    // number of data acknowledged by all sinks
+
+	ackMu sync.Mutex // to handle acknowledged data in sequence
+}
+
+// NewBroadcaster creates a new broadcaster with the given sinks. Note, Start should be called to process data
+// in sinks.
+func NewBroadcaster[T channel.Sizable](sinks []Sink[T], option ...BroadcasterOption[T]) *Broadcaster[T] {
+	var opt BroadcasterOption[T]
+	if len(option) > 0 {
+		opt = option[0]
+	}
+
+	defaults.SetDefaults(&opt)
+
+	broadcaster := Broadcaster[T]{
+		window:     make(chan struct{}, opt.MaxPending),
+		checkpoint: opt.Checkpoint,
+	}
+
+	for _, v := range sinks {
+		defaults.SetDefaults(&v.Option)
+
+		broadcaster.sinks = append(broadcaster.sinks, &sink[T]{
+			option:    v.Option,
+			processor: v.Processor,
+			ch:        channel.NewMemoryBoundedChannel[T](v.Option.Buffer.Capacity, v.Option.Buffer.MaxBytes),
+			health:    health.NewTimedCounter(v.Option.Health),
+		})
+	}
+
+	return &broadcaster
+}
+
+// Process implements the Processor[T] interface, which writes the given data into the buffer of all sinks.
+//
+// Note, if context done before data written into the buffer of all sinks, the data will never be acknowledged,
+// and sinks that already received the data may still process it. So, Broadcaster should not be used anymore
+// once context done.
+func (broadcaster *Broadcaster[T]) Process(ctx context.Context, data T) {
+	if err := ctxutil.WriteChannel(ctx, broadcaster.window, struct{}{}); err != nil {
+		return
+	}
+
+	broadcaster.mu.Lock()
+	broadcaster.pending = append(broadcaster.pending, pendingData[T]{data, len(broadcaster.sinks)})
+	broadcaster.mu.Unlock()
+
+	for _, v := range broadcaster.sinks {
+		if err := ctxutil.WriteChannel(ctx, v.ch.SendCh(), data); err != nil {
+			broadcaster.cancel()
+			return
+		}
+	}
+}
+
+// cancel removes the last pending data that failed to write into the buffer of all sinks.
+func (broadcaster *Broadcaster[T]) cancel() {
+	broadcaster.mu.Lock()
+	broadcaster.pending = broadcaster.pending[:len(broadcaster.pending)-1]
+	broadcaster.mu.Unlock()
+
+	<-broadcaster.window
+}
+
+// Start starts to process data in all sinks in separate goroutines, which terminate if the given context done
+// or Close called.
+func (broadcaster *Broadcaster[T]) Start(ctx context.Context, wg *sync.WaitGroup) {
+	for _, v := range broadcaster.sinks {
+		wg.Add(1)
+		go broadcaster.loop(ctx, wg, v)
+	}
+}
+
+// Close closes the buffer of all sinks, so that sinks terminate after all buffered data processed.
+func (broadcaster *Broadcaster[T]) Close() {
+	for _, v := range broadcaster.sinks {
+		v.ch.Close()
+	}
+}
+
+// Acknowledged returns the number of data processed by all sinks, which is thread-safe.
+func (broadcaster *Broadcaster[T]) Acknowledged() uint64 {
+	return broadcaster.acknowledged.Load()
+}
+
+// Cursors returns the number of data processed by each sink, which is thread-safe.
+func (broadcaster *Broadcaster[T]) Cursors() map[string]uint64 {
+	cursors := make(map[string]uint64, len(broadcaster.sinks))
+
+	for _, v := range broadcaster.sinks {
+		cursors[v.option.Name] = v.cursor.Load()
+	}
+
+	return cursors
+}
+
+func (broadcaster *Broadcaster[T]) loop(ctx context.Context, wg *sync.WaitGroup, sink *sink[T]) {
+	defer wg.Done()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case data, ok := <-sink.ch.RecvCh():
+			if !ok {
+				return
+			}
+
+			if !broadcaster.processWithRetry(ctx, sink, data) {
+				return
+			}
+
+			broadcaster.ack(ctx, sink.cursor.Add(1)-1)
+		}
+	}
+}
+
+// processWithRetry processes data in sink till succeeded, and returns false if context done.
+func (broadcaster *Broadcaster[T]) processWithRetry(ctx context.Context, sink *sink[T], data T) bool {
+	interval := sink.option.RetryInterval
+
+	for {
+		err := sink.tryProcess(ctx, data)
+		if ctxutil.IsDone(ctx) {
+			return false
+		}
+
+		sink.health.LogOnError(err, "Process blockchain data in sink "+sink.option.Name)
+
+		if err == nil {
+			return true
+		}
+
+		log.WithModule(ModuleName).WithError(err).WithFields(logrus.Fields{
+			"sink":     sink.option.Name,
+			"sequence": sink.cursor.Load(),
+		}).Debug("Failed to process data in sink")
+
+		if ctxutil.Sleep(ctx, interval) != nil {
+			return false
+		}
+
+		interval = min(interval*2, sink.option.MaxRetryInterval)
+	}
+}
+
+// tryProcess processes data in sink, and regards panic as error if processor is not a FallibleProcessor.
+func (sink *sink[T]) tryProcess(ctx context.Context, data T) (err error) {
+	if fallible, ok := sink.processor.(FallibleProcessor[T]); ok {
+		return fallible.TryProcess(ctx, data)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panicked: %v", r)
+		}
+	}()
+
+	sink.processor.Process(ctx, data)
+
+	return nil
+}
+
+// ack acknowledges the data of given sequence for a sink, and handles data that acknowledged by all sinks
+// in sequence.
+func (broadcaster *Broadcaster[T]) ack(ctx context.Context, sequence uint64) {
+	broadcaster.ackMu.Lock()
+	defer broadcaster.ackMu.Unlock()
+
+	for _, data := range broadcaster.popAcknowledged(sequence) {
+		if broadcaster.checkpoint != nil {
+			broadcaster.checkpoint.Process(ctx, data)
+		}
+
+		broadcaster.acknowledged.Add(1)
+
+		<-broadcaster.window
+	}
+}
+
+// popAcknowledged acknowledges the data of given sequence for a sink, and removes the data that acknowledged
+// by all sinks from pending.
+func (broadcaster *Broadcaster[T]) popAcknowledged(sequence uint64) []T {
+	broadcaster.mu.Lock()
+	defer broadcaster.mu.Unlock()
+
+	// data has been cancelled
+	if sequence-broadcaster.base >= uint64(len(broadcaster.pending)) {
+		return nil
+	}
+
+	broadcaster.pending[sequence-broadcaster.base].pending--
+
+	var acknowledged []T
+	for len(broadcaster.pending) > 0 && broadcaster.pending[0].pending == 0 {
+		acknowledged = append(acknowledged, broadcaster.pending[0].data)
+		broadcaster.pending = broadcaster.pending[1:]
+		broadcaster.base++
+	}
+
+	return acknowledged
+}
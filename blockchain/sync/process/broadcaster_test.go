@@ -0,0 +1,150 @@
+package process
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testData int
+
+func (data testData) Size() int {
+	return 8
+}
+
+type testSink struct {
+	mu        sync.Mutex
+	processed []testData
+}
+
+func (sink *testSink) Process(ctx context.Context, data testData) {
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+
+	sink.processed = append(sink.processed, data)
+}
+
+type testFallibleSink struct {
+	testSink
+	failures int // number of failures before succeeded
+}
+
+func (sink *testFallibleSink) TryProcess(ctx context.Context, data testData) error {
+	sink.mu.Lock()
+	if sink.failures > 0 {
+		sink.failures--
+		sink.mu.Unlock()
+		return errors.New("mock error")
+	}
+	sink.mu.Unlock()
+
+	sink.Process(ctx, data)
+
+	return nil
+}
+
+type testPanicSink struct {
+	testSink
+	failures int // number of panics before succeeded
+}
+
+func (sink *testPanicSink) Process(ctx context.Context, data testData) {
+	sink.mu.Lock()
+	if sink.failures > 0 {
+		sink.failures--
+		sink.mu.Unlock()
+		panic("mock error")
+	}
+	sink.mu.Unlock()
+
+	sink.testSink.Process(ctx, data)
+}
+
+func TestBroadcaster(t *testing.T) {
+	fast := testPanicSink{failures: 1}
+	slow := testFallibleSink{failures: 2}
+
+	var checkpoint testSink
+	broadcaster := NewBroadcaster([]Sink[testData]{
+		{Processor: &fast, Option: SinkOption{Name: "fast", RetryInterval: time.Millisecond}},
+		{Processor: &slow, Option: SinkOption{Name: "slow", RetryInterval: time.Millisecond}},
+	}, BroadcasterOption[testData]{Checkpoint: &checkpoint})
+
+	var wg sync.WaitGroup
+	broadcaster.Start(context.Background(), &wg)
+
+	for i := 1; i <= 3; i++ {
+		broadcaster.Process(context.Background(), testData(i))
+	}
+
+	broadcaster.Close()
+	wg.Wait()
+
+	expected := []testData{1, 2, 3}
+	assert.Equal(t, expected, fast.processed)
+	assert.Equal(t, expected, slow.processed)
+	assert.Equal(t, expected, checkpoint.processed)
+	assert.Equal(t, uint64(3), broadcaster.Acknowledged())
+	assert.Equal(t, map[string]uint64{"fast": 3, "slow": 3}, broadcaster.Cursors())
+}
+
+func TestBroadcasterBackpressure(t *testing.T) {
+	var sink testSink
+	broadcaster := NewBroadcaster([]Sink[testData]{
+		{Processor: &sink, Option: SinkOption{Name: "sink"}},
+	}, BroadcasterOption[testData]{MaxPending: 1})
+
+	broadcaster.Process(context.Background(), 1)
+
+	// blocked since data not acknowledged yet
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	broadcaster.Process(ctx, 2)
+	assert.Len(t, broadcaster.pending, 1)
+
+	var wg sync.WaitGroup
+	broadcaster.Start(context.Background(), &wg)
+
+	broadcaster.Process(context.Background(), 3)
+
+	broadcaster.Close()
+	wg.Wait()
+
+	assert.Equal(t, []testData{1, 3}, sink.processed)
+	assert.Equal(t, uint64(2), broadcaster.Acknowledged())
+}
+
+func TestBroadcasterCancelled(t *testing.T) {
+	var fast, slow, checkpoint testSink
+	broadcaster := NewBroadcaster([]Sink[testData]{
+		{Processor: &fast, Option: SinkOption{Name: "fast"}},
+		{Processor: &slow, Option: SinkOption{Name: "slow"}},
+	}, BroadcasterOption[testData]{Checkpoint: &checkpoint})
+
+	// slow sink not started, so that its buffer will be full
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go broadcaster.loop(context.Background(), &wg, broadcaster.sinks[0])
+
+	for i := 1; ctx.Err() == nil; i++ {
+		broadcaster.Process(ctx, testData(i))
+	}
+
+	wg.Add(1)
+	go broadcaster.loop(context.Background(), &wg, broadcaster.sinks[1])
+
+	broadcaster.Close()
+	wg.Wait()
+
+	// data that not written into the buffer of all sinks is never acknowledged
+	assert.Equal(t, slow.processed, checkpoint.processed)
+	assert.Equal(t, uint64(len(slow.processed)), broadcaster.Acknowledged())
+	assert.Empty(t, broadcaster.pending)
+}
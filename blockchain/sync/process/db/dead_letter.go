@@ -0,0 +1,230 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Conflux-Chain/go-conflux-util/alert"
+	"github.com/Conflux-Chain/go-conflux-util/log"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// DeadLetter is the database model to persist blockchain data that failed to write into database.
+type DeadLetter struct {
+	ID          uint64
+	Name        string `gorm:"size:64;not null;index:idx_name_number,priority:1"`
+	BlockNumber uint64 `gorm:"not null;index:idx_name_number,priority:2"`
+	Blocks      int    `gorm:"not null"` // number of blocks, which is always 1 since batch is written block by block once failed
+	Error       string `gorm:"type:text"`
+	Payload     string `gorm:"type:mediumtext"` // blockchain data in JSON format, empty if written via RetriableProcessor.Write
+	CreatedAt   time.Time
+}
+
+func (DeadLetter) TableName() string {
+	return "sync_dead_letters"
+}
+
+type DeadLetterOption struct {
+	// Enabled indicates whether to handle the poison data that always failed to write into database,
+	// otherwise, retry forever.
+	Enabled bool
+
+	// Name is used to distinguish dead letters of multiple sync tasks in the same database table.
+	Name string `default:"default"`
+
+	// MaxAttempts is the max number of attempts to write database for transient errors, while permanent
+	// errors will not be retried. See IsPermanentError for more details.
+	MaxAttempts int `default:"10"`
+
+	// Skip indicates to skip the poison data once persisted in dead letter table, otherwise, stop the
+	// pipeline with an alert.
+	Skip bool
+
+	// AlertChannel is the name of alert channel, which should be configured in alert.DefaultManager().
+	AlertChannel string
+	AlertTimeout time.Duration `default:"5s"`
+}
+
+// Numbered is optionally implemented by blockchain data to persist the block number in dead letters.
+type Numbered interface {
+	BlockNumber() uint64
+}
+
+// PermanentError indicates the error will not be resolved by retry, e.g. bad data.
+type PermanentError struct {
+	Err error
+}
+
+// NewPermanentError wraps the given error as a permanent error, which could be returned by Operation.Exec
+// so that the poison data will be handled without retry.
+func NewPermanentError(err error) *PermanentError {
+	return &PermanentError{err}
+}
+
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+// IsPermanentError returns true if the given error is a PermanentError or any constraint violation error,
+// which requires the gorm config TranslateError enabled.
+func IsPermanentError(err error) bool {
+	if permanentErr := (*PermanentError)(nil); errors.As(err, &permanentErr) {
+		return true
+	}
+
+	return errors.Is(err, gorm.ErrDuplicatedKey) ||
+		errors.Is(err, gorm.ErrForeignKeyViolated) ||
+		errors.Is(err, gorm.ErrCheckConstraintViolated) ||
+		errors.Is(err, gorm.ErrInvalidData)
+}
+
+// newDeadLetter creates a dead letter for the given blockchain data.
+func newDeadLetter(data any) DeadLetter {
+	letter := DeadLetter{Blocks: 1}
+
+	if numbered, ok := data.(Numbered); ok {
+		letter.BlockNumber = numbered.BlockNumber()
+	}
+
+	if payload, err := json.Marshal(data); err != nil {
+		log.WithModule(ModuleName).WithError(err).Warn("Failed to marshal blockchain data for dead letter")
+	} else {
+		letter.Payload = string(payload)
+	}
+
+	return letter
+}
+
+// deadLetter persists the given dead letter for the failed database operation, and then skips the operation
+// or terminates the process with an alert.
+func (processor *RetriableProcessor) deadLetter(ctx context.Context, letter DeadLetter, err error) {
+	option := processor.option.DeadLetter
+
+	letter.Name = option.Name
+	letter.Error = err.Error()
+
+	logger := log.WithModule(ModuleName).WithError(err).WithFields(logrus.Fields{
+		"name":   letter.Name,
+		"block":  letter.BlockNumber,
+		"blocks": letter.Blocks,
+	})
+
+	// write dead letter till succeeded
+	for {
+		err := processor.migrateDeadLetter()
+		if err == nil {
+			err = processor.db.Create(&letter).Error
+		}
+
+		if err == nil {
+			break
+		}
+
+		logger.WithField("deadLetterError", err).Warn("Failed to write dead letter")
+
+		if processor.sleep(ctx) != nil {
+			return
+		}
+	}
+
+	processor.deadLetters.Inc(1)
+
+	if option.Skip {
+		logger.WithField("id", letter.ID).Warn("Skipped poison data that failed to write database")
+		go processor.alert(letter, alert.SeverityHigh)
+		return
+	}
+
+	processor.alert(letter, alert.SeverityCritical)
+
+	logger.WithField("id", letter.ID).Fatal("Stopped due to poison data that failed to write database")
+}
+
+// migrateDeadLetter creates the dead letter table if not created yet, which is lazily created on the first dead letter.
+func (processor *RetriableProcessor) migrateDeadLetter() error {
+	if processor.deadLetterMigrated.Load() {
+		return nil
+	}
+
+	if err := processor.db.AutoMigrate(&DeadLetter{}); err != nil {
+		return errors.WithMessage(err, "Failed to create dead letter table")
+	}
+
+	processor.deadLetterMigrated.Store(true)
+
+	return nil
+}
+
+func (processor *RetriableProcessor) alert(letter DeadLetter, severity alert.Severity) {
+	option := processor.option.DeadLetter
+
+	if option.AlertChannel == "" {
+		return
+	}
+
+	channel, ok := alert.DefaultManager().Channel(option.AlertChannel)
+	if !ok {
+		log.WithModule(ModuleName).WithField("channel", option.AlertChannel).Warn("Alert channel not found for dead letter")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), option.AlertTimeout)
+	defer cancel()
+
+	err := channel.Send(ctx, &alert.Notification{
+		Title: "Failed to write blockchain data into database",
+		Content: fmt.Sprintf("name = %v, block = %v, blocks = %v, dead letter = %v, error = %v",
+			letter.Name, letter.BlockNumber, letter.Blocks, letter.ID, letter.Error),
+		Severity: severity,
+	})
+
+	if err != nil {
+		log.WithModule(ModuleName).WithError(err).Warn("Failed to send alert for dead letter")
+	}
+}
+
+// ReplayDeadLetters processes the dead letters of the given name again with the given processors in ascending
+// order of block number, and deletes them once succeeded. It returns the number of replayed dead letters.
+//
+// Note, dead letters without payload are ignored, e.g. written via RetriableProcessor.Write. Please re-process
+// the block range via backfill instead.
+func ReplayDeadLetters[T any](db *gorm.DB, name string, processors ...Processor[T]) (int, error) {
+	if !db.Migrator().HasTable(&DeadLetter{}) {
+		return 0, nil
+	}
+
+	var letters []DeadLetter
+	if err := db.Where("name = ? AND payload <> ''", name).Order("block_number ASC, id ASC").Find(&letters).Error; err != nil {
+		return 0, errors.WithMessage(err, "Failed to load dead letters")
+	}
+
+	for i, v := range letters {
+		var data T
+		if err := json.Unmarshal([]byte(v.Payload), &data); err != nil {
+			return i, errors.WithMessagef(err, "Failed to unmarshal payload of dead letter %v", v.ID)
+		}
+
+		ops := make([]Operation, 0, len(processors)+1)
+		for _, processor := range processors {
+			ops = append(ops, processor.Process(data))
+		}
+
+		ops = append(ops, DeleteOperation(&DeadLetter{}, "id = ?", v.ID))
+
+		if err := db.Transaction(func(tx *gorm.DB) error {
+			return ComposeOperation(ops...).Exec(tx)
+		}); err != nil {
+			return i, errors.WithMessagef(err, "Failed to replay dead letter %v", v.ID)
+		}
+	}
+
+	return len(letters), nil
+}
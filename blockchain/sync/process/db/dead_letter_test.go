@@ -0,0 +1,152 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Conflux-Chain/go-conflux-util/blockchain/sync/poll"
+	"github.com/Conflux-Chain/go-conflux-util/blockchain/sync/poll/testutil"
+	"github.com/Conflux-Chain/go-conflux-util/store"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type deadLetterTestBlock struct {
+	Number uint64 `gorm:"primaryKey"`
+	Hash   string
+}
+
+type deadLetterTestProcessor struct {
+	poison uint64
+}
+
+func (processor deadLetterTestProcessor) Process(data testutil.Data) Operation {
+	if data.Number == processor.poison {
+		return errorOperation{NewPermanentError(errors.New("bad data"))}
+	}
+
+	return CreateOperation(&deadLetterTestBlock{data.Number, data.Hash})
+}
+
+type errorOperation struct {
+	err error
+}
+
+func (op errorOperation) Exec(tx *gorm.DB) error {
+	return op.err
+}
+
+func TestIsPermanentError(t *testing.T) {
+	assert.False(t, IsPermanentError(errors.New("timeout")))
+	assert.True(t, IsPermanentError(NewPermanentError(errors.New("bad data"))))
+	assert.True(t, IsPermanentError(gorm.ErrDuplicatedKey))
+}
+
+func TestDeadLetter(t *testing.T) {
+	storeConfig := store.NewMemoryConfig()
+	DB := storeConfig.MustOpenOrCreate()
+	assert.NoError(t, DB.AutoMigrate(&deadLetterTestBlock{}))
+
+	processor := NewAggregateProcessor[testutil.Data](Option{
+		RetryInterval: time.Millisecond,
+		DeadLetter:    DeadLetterOption{Enabled: true, Name: "test", MaxAttempts: 3, Skip: true},
+	}, DB, deadLetterTestProcessor{poison: 2})
+	assert.False(t, DB.Migrator().HasTable(&DeadLetter{})) // created on the first dead letter
+
+	// poison data skipped
+	for i := uint64(1); i <= 3; i++ {
+		processor.Process(context.Background(), testutil.Data{Number: i, Hash: "hash"})
+	}
+
+	var blocks []deadLetterTestBlock
+	assert.NoError(t, DB.Order("number").Find(&blocks).Error)
+	assert.Equal(t, []deadLetterTestBlock{{1, "hash"}, {3, "hash"}}, blocks)
+
+	var letters []DeadLetter
+	assert.NoError(t, DB.Find(&letters).Error)
+	assert.Equal(t, 1, len(letters))
+	assert.Equal(t, "test", letters[0].Name)
+	assert.Equal(t, uint64(2), letters[0].BlockNumber)
+	assert.Equal(t, "bad data", letters[0].Error)
+	assert.Equal(t, `{"Number":2,"Hash":"hash","ParentHash":""}`, letters[0].Payload)
+
+	// replay once fixed
+	replayed, err := ReplayDeadLetters[testutil.Data](DB, "test", deadLetterTestProcessor{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, replayed)
+
+	assert.NoError(t, DB.Order("number").Find(&blocks).Error)
+	assert.Equal(t, []deadLetterTestBlock{{1, "hash"}, {2, "hash"}, {3, "hash"}}, blocks)
+
+	var count int64
+	assert.NoError(t, DB.Model(&DeadLetter{}).Count(&count).Error)
+	assert.Equal(t, int64(0), count)
+}
+
+// revertableDeadLetterTestProcessor treats data as poison by block hash, so that a reverted block could be poison.
+type revertableDeadLetterTestProcessor struct{}
+
+func (processor revertableDeadLetterTestProcessor) Process(data testutil.Data) Operation {
+	if data.Hash == "poison" {
+		return errorOperation{NewPermanentError(errors.New("bad data"))}
+	}
+
+	return CreateOperation(&deadLetterTestBlock{data.Number, data.Hash})
+}
+
+func (processor revertableDeadLetterTestProcessor) Revert(data testutil.Data) Operation {
+	return DeleteOperation(&deadLetterTestBlock{}, "number >= ?", data.Number)
+}
+
+func TestRevertableDeadLetter(t *testing.T) {
+	storeConfig := store.NewMemoryConfig()
+	DB := storeConfig.MustOpenOrCreate()
+	assert.NoError(t, DB.AutoMigrate(&deadLetterTestBlock{}))
+
+	processor := NewRevertableAggregateProcessor[testutil.Data](Option{
+		RetryInterval: time.Millisecond,
+		DeadLetter:    DeadLetterOption{Enabled: true, Name: "test", MaxAttempts: 3, Skip: true},
+	}, DB, revertableDeadLetterTestProcessor{})
+
+	processor.Process(context.Background(), poll.Revertable[testutil.Data]{Data: testutil.Data{Number: 1, Hash: "hash"}})
+	processor.Process(context.Background(), poll.Revertable[testutil.Data]{Data: testutil.Data{Number: 2, Hash: "hash"}})
+
+	// block 2 reverted, and the new block 2 is poison data
+	processor.Process(context.Background(), poll.Revertable[testutil.Data]{
+		Data:     testutil.Data{Number: 2, Hash: "poison"},
+		Reverted: true,
+	})
+
+	// orphaned data deleted even though the new data skipped
+	var blocks []deadLetterTestBlock
+	assert.NoError(t, DB.Order("number").Find(&blocks).Error)
+	assert.Equal(t, []deadLetterTestBlock{{1, "hash"}}, blocks)
+
+	var letters []DeadLetter
+	assert.NoError(t, DB.Find(&letters).Error)
+	assert.Equal(t, 1, len(letters))
+	assert.Equal(t, uint64(2), letters[0].BlockNumber)
+	assert.Equal(t, `{"Number":2,"Hash":"poison","ParentHash":""}`, letters[0].Payload)
+}
+
+func TestDeadLetterContextDone(t *testing.T) {
+	storeConfig := store.NewMemoryConfig()
+	DB := storeConfig.MustOpenOrCreate()
+
+	processor := NewRetriableProcessor(DB, Option{
+		RetryInterval: time.Millisecond,
+		DeadLetter:    DeadLetterOption{Enabled: true, Name: "test", MaxAttempts: 3},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// transient error not handled by dead letter policy once context done
+	op := errorOperation{errors.New("transient error")}
+	assert.ErrorIs(t, processor.tryWrite(ctx, op), context.Canceled)
+
+	processor.Write(ctx, op)
+	assert.False(t, DB.Migrator().HasTable(&DeadLetter{}))
+}
@@ -11,6 +11,15 @@ type Operation interface {
 
 ////////////////////////////////////////////////////////////////////////
 
+// OperationFunc is an adapter to allow the use of ordinary function as database operation.
+type OperationFunc func(tx *gorm.DB) error
+
+func (fn OperationFunc) Exec(tx *gorm.DB) error {
+	return fn(tx)
+}
+
+////////////////////////////////////////////////////////////////////////
+
 type compositeOperation struct {
 	ops []Operation
 }
@@ -42,7 +51,13 @@ func CreateOperation(models ...any) Operation {
 }
 
 func (op createOperation) Exec(tx *gorm.DB) error {
-	return tx.Create(op.models).Error
+	for _, v := range op.models {
+		if err := tx.Create(v).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 ////////////////////////////////////////////////////////////////////////
@@ -60,3 +75,18 @@ func DeleteOperation(modelPtr any, conds ...any) Operation {
 func (op deleteOperation) Exec(tx *gorm.DB) error {
 	return tx.Delete(op.modelPtr, op.conds...).Error
 }
+
+////////////////////////////////////////////////////////////////////////
+
+// CreateInBatches creates the given models in batch, and creates all models at a time if createBatchSize <= 0.
+func CreateInBatches[T any](tx *gorm.DB, models []T, createBatchSize int) error {
+	if len(models) == 0 {
+		return nil
+	}
+
+	if createBatchSize <= 0 {
+		createBatchSize = len(models)
+	}
+
+	return tx.CreateInBatches(models, createBatchSize).Error
+}
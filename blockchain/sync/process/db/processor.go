@@ -2,12 +2,17 @@ package db
 
 import (
 	"context"
+	"sync/atomic"
 	"time"
 
+	"github.com/Conflux-Chain/go-conflux-util/blockchain/sync/poll"
 	"github.com/Conflux-Chain/go-conflux-util/ctxutil"
 	"github.com/Conflux-Chain/go-conflux-util/health"
 	"github.com/Conflux-Chain/go-conflux-util/log"
+	"github.com/Conflux-Chain/go-conflux-util/metrics"
 	"github.com/mcuadros/go-defaults"
+	"github.com/pkg/errors"
+	gometrics "github.com/rcrowley/go-metrics"
 	"gorm.io/gorm"
 )
 
@@ -17,13 +22,25 @@ type Option struct {
 	RetryInterval time.Duration `default:"3s"`
 
 	Health health.TimedCounterConfig
+
+	Metrics poll.MetricsOption
+
+	// DeadLetter is used to handle the poison data that always failed to write into database.
+	DeadLetter DeadLetterOption
 }
 
-// RetriableProcessor operates on database till succeeded.
+// RetriableProcessor operates on database till succeeded, unless the dead letter policy enabled.
 type RetriableProcessor struct {
 	option Option
 	db     *gorm.DB
 	health *health.TimedCounter
+
+	writeTimer    gometrics.Timer   // latency of succeeded database write
+	writeFailures gometrics.Counter // number of failed database write
+	blocks        gometrics.Meter   // number of processed blocks
+	deadLetters   gometrics.Counter // number of persisted dead letters
+
+	deadLetterMigrated atomic.Bool // whether the dead letter table created
 }
 
 func NewRetriableProcessor(db *gorm.DB, option Option) *RetriableProcessor {
@@ -33,12 +50,44 @@ func NewRetriableProcessor(db *gorm.DB, option Option) *RetriableProcessor {
 		option: option,
 		db:     db,
 		health: health.NewTimedCounter(option.Health),
+
+		writeTimer:    metrics.GetOrRegisterTimer("%v", option.Metrics.Name("process/db/write")),
+		writeFailures: metrics.GetOrRegisterCounter("%v", option.Metrics.Name("process/db/write/failures")),
+		blocks:        metrics.GetOrRegisterMeter("%v", option.Metrics.Name("process/db/blocks")),
+		deadLetters:   metrics.GetOrRegisterCounter("%v", option.Metrics.Name("process/db/deadletters")),
 	}
 }
 
 // Write executes the given op in a transaction. If failed, it will try again till succeeded.
+//
+// If dead letter policy enabled, the op will be skipped or the process will be terminated once the max attempts
+// reached or any permanent error occurred. Note, the blockchain data is not persisted in dead letter, please use
+// WriteData instead.
 func (processor *RetriableProcessor) Write(ctx context.Context, op Operation) {
-	for {
+	processor.writeOrDeadLetter(ctx, op, func() DeadLetter { return DeadLetter{Blocks: 1} })
+}
+
+// WriteData is similar to Write, but persists the given blockchain data in dead letter if failed, which could be
+// replayed later via ReplayDeadLetters.
+func (processor *RetriableProcessor) WriteData(ctx context.Context, op Operation, data any) {
+	processor.writeOrDeadLetter(ctx, op, func() DeadLetter { return newDeadLetter(data) })
+}
+
+// writeOrDeadLetter executes the given op in a transaction till succeeded or context done, unless handled by
+// dead letter policy.
+func (processor *RetriableProcessor) writeOrDeadLetter(ctx context.Context, op Operation, letter func() DeadLetter) {
+	if err := processor.tryWrite(ctx, op); err != nil && !errors.Is(err, ctx.Err()) {
+		processor.deadLetter(ctx, letter(), err)
+	}
+}
+
+// tryWrite executes the given op in a transaction till succeeded or context done, in which case ctx.Err() is
+// returned. If dead letter policy enabled, it returns the last error once the max attempts reached or any
+// permanent error occurred.
+func (processor *RetriableProcessor) tryWrite(ctx context.Context, op Operation) error {
+	for attempts := 1; ; attempts++ {
+		start := time.Now()
+
 		err := processor.db.Transaction(func(tx *gorm.DB) error {
 			return op.Exec(tx)
 		})
@@ -46,13 +95,24 @@ func (processor *RetriableProcessor) Write(ctx context.Context, op Operation) {
 		processor.health.LogOnError(err, "Process blockchain data in Database")
 
 		if err == nil {
-			return
+			processor.writeTimer.UpdateSince(start)
+			return nil
 		}
 
-		log.WithModule(ModuleName).WithError(err).Debug("Failed to write database")
+		processor.writeFailures.Inc(1)
+
+		log.WithModule(ModuleName).WithError(err).WithField("attempts", attempts).Debug("Failed to write database")
 
-		if err = ctxutil.Sleep(ctx, processor.option.RetryInterval); err != nil {
-			return
+		if option := processor.option.DeadLetter; option.Enabled && (attempts >= option.MaxAttempts || IsPermanentError(err)) {
+			return err
+		}
+
+		if err = processor.sleep(ctx); err != nil {
+			return err
 		}
 	}
 }
+
+func (processor *RetriableProcessor) sleep(ctx context.Context) error {
+	return ctxutil.Sleep(ctx, processor.option.RetryInterval)
+}
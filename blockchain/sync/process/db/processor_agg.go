@@ -27,6 +27,12 @@ func NewAggregateProcessor[T any](option Option, db *gorm.DB, processors ...Proc
 
 // Process implements the process.Processor[T] interface.
 func (processor *AggregateProcessor[T]) Process(ctx context.Context, data T) {
+	processor.WriteData(ctx, processor.operation(data), data)
+	processor.blocks.Mark(1)
+}
+
+// operation returns the composed database operation of all processors to process the given data.
+func (processor *AggregateProcessor[T]) operation(data T) Operation {
 	var ops []Operation
 
 	for _, v := range processor.processors {
@@ -34,5 +40,5 @@ func (processor *AggregateProcessor[T]) Process(ctx context.Context, data T) {
 		ops = append(ops, op)
 	}
 
-	processor.Write(ctx, ComposeOperation(ops...))
+	return ComposeOperation(ops...)
 }
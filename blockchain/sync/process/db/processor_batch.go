@@ -2,10 +2,15 @@ package db
 
 import (
 	"context"
+	"fmt"
 	"time"
 
+	"github.com/Conflux-Chain/go-conflux-util/blockchain/sync/process"
+	"github.com/Conflux-Chain/go-conflux-util/channel"
+	"github.com/Conflux-Chain/go-conflux-util/ctxutil"
 	"github.com/Conflux-Chain/go-conflux-util/log"
 	"github.com/mcuadros/go-defaults"
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
@@ -25,14 +30,57 @@ type BatchProcessor[T any] interface {
 	BatchReset()
 }
 
+// PipelineBatchProcessor is optionally implemented by BatchProcessor[T] to write database in background
+// while building the next batch.
+type PipelineBatchProcessor interface {
+	// BatchDetach returns an operation to write the current batch, and resets data for the next batch.
+	//
+	// Note, the returned operation will be executed in another goroutine, so it should not share any
+	// mutable data with the processor.
+	BatchDetach(createBatchSize int) Operation
+}
+
+// SavepointBatchProcessor is optionally implemented by BatchProcessor[T] that maintains states in memory, e.g.
+// checkpoint, so that the states could be rolled back once the batch failed to write into database.
+type SavepointBatchProcessor interface {
+	// BatchSavepoint is executed before the first data in batch processed, and returns a function to roll back
+	// the in-memory states to the savepoint.
+	BatchSavepoint() func()
+}
+
+// batchData holds the blockchain data in batch, so that the batch could be written block by block to isolate the
+// poison data once failed. It is only used when dead letter policy enabled.
+type batchData[T any] struct {
+	data       []T
+	savepoints []func() // to roll back the in-memory states of processors before the batch processed
+}
+
 type BatchOption struct {
 	Option `mapstructure:",squash"`
 
 	BatchSize       int           `default:"3000"`
 	BatchTimeout    time.Duration `default:"3s"`
 	CreateBatchSize int           `default:"1000"`
+
+	// BatchBytes is the max memory size of blockchain data in batch, which requires the data to implement
+	// the channel.Sizable interface. Disabled if 0.
+	BatchBytes int
+
+	// FlushInterval is the interval to check batch timeout in background, so that the pending batch will be
+	// written into database in time even though no more data polled.
+	FlushInterval time.Duration `default:"1s"`
+
+	// Pipeline indicates to write batch into database in background while building the next batch, which
+	// requires all processors to implement the PipelineBatchProcessor interface. Note, batches are written in
+	// order, and at most one batch is written in background.
+	Pipeline bool
 }
 
+var (
+	_ process.TickProcessor  = (*BatchAggregateProcessor[any])(nil)
+	_ process.CloseProcessor = (*BatchAggregateProcessor[any])(nil)
+)
+
 // BatchProcessor aggregates multiple processor to process blockchain data in batch.
 //
 // Generally, it is used during catch up phase.
@@ -43,11 +91,29 @@ type BatchAggregateProcessor[T any] struct {
 	processors    []BatchProcessor[T]
 	lastBatchTime time.Time
 	size          int
+	bytes         int           // memory size of blockchain data in batch if data implements the channel.Sizable interface
+	batchFirst    uint64        // the first block number in batch if data implements the Numbered interface
+	batchBlocks   int           // number of blocks in batch
+	batch         batchData[T]  // blockchain data in batch if dead letter policy enabled
+	written       chan struct{} // closed once the batch written in background, nil if none
+	failed        *batchData[T] // batch that failed to write in background, nil if none
 }
 
 func NewBatchAggregateProcessor[T any](option BatchOption, db *gorm.DB, processors ...BatchProcessor[T]) *BatchAggregateProcessor[T] {
 	defaults.SetDefaults(&option)
 
+	if option.Pipeline {
+		for _, v := range processors {
+			if _, ok := v.(PipelineBatchProcessor); !ok {
+				log.WithModule(ModuleName).WithField("processor", fmt.Sprintf("%T", v)).Warn(
+					"Pipeline disabled since processor does not implement the PipelineBatchProcessor interface",
+				)
+				option.Pipeline = false
+				break
+			}
+		}
+	}
+
 	return &BatchAggregateProcessor[T]{
 		RetriableProcessor: NewRetriableProcessor(db, option.Option),
 		option:             option,
@@ -58,41 +124,219 @@ func NewBatchAggregateProcessor[T any](option BatchOption, db *gorm.DB, processo
 
 // Process implements the process.CatchUpProcessor[T] interface.
 func (processor *BatchAggregateProcessor[T]) Process(ctx context.Context, data T) {
+	processor.add(data)
+	processor.blocks.Mark(1)
+
+	// Write database only if batch size reached or batch timeout. Note, if no more data polled,
+	// the pending batch will be written in OnTick once batch timeout.
+	if processor.size < processor.option.BatchSize && !processor.bytesReached() && !processor.timeout() {
+		return
+	}
+
+	processor.write(ctx)
+}
+
+// add processes the given data in batch.
+func (processor *BatchAggregateProcessor[T]) add(data T) {
+	if processor.batchBlocks == 0 {
+		if numbered, ok := any(data).(Numbered); ok {
+			processor.batchFirst = numbered.BlockNumber()
+		}
+
+		if processor.option.DeadLetter.Enabled {
+			processor.batch.savepoints = processor.savepoints()
+		}
+	}
+
+	processor.batchBlocks++
 	processor.size = 0
 
 	for _, v := range processor.processors {
 		processor.size += v.BatchProcess(data)
 	}
 
-	// Write database only if batch size reached or batch timeout.
-	//
-	// Note, if no more data polled, it will not write database even though batch timeout.
-	// This situation will rarely happen in catch up phase, and the worst case is that
-	// only one batch data not written into database in time.
-	if processor.size < processor.option.BatchSize && time.Since(processor.lastBatchTime) < processor.option.BatchTimeout {
-		return
+	if sizable, ok := any(data).(channel.Sizable); ok && processor.option.BatchBytes > 0 {
+		processor.bytes += sizable.Size()
 	}
 
-	processor.write(ctx)
+	if processor.option.DeadLetter.Enabled {
+		processor.batch.data = append(processor.batch.data, data)
+	}
+}
+
+func (processor *BatchAggregateProcessor[T]) savepoints() []func() {
+	var savepoints []func()
+
+	for _, v := range processor.processors {
+		if savepoint, ok := v.(SavepointBatchProcessor); ok {
+			savepoints = append(savepoints, savepoint.BatchSavepoint())
+		}
+	}
+
+	return savepoints
+}
+
+func (processor *BatchAggregateProcessor[T]) bytesReached() bool {
+	return processor.option.BatchBytes > 0 && processor.bytes >= processor.option.BatchBytes
+}
+
+func (processor *BatchAggregateProcessor[T]) timeout() bool {
+	return time.Since(processor.lastBatchTime) >= processor.option.BatchTimeout
+}
+
+// TickInterval implements the process.TickProcessor interface.
+func (processor *BatchAggregateProcessor[T]) TickInterval() time.Duration {
+	return processor.option.FlushInterval
+}
+
+// OnTick implements the process.TickProcessor interface, which writes the pending batch into database
+// once batch timeout.
+func (processor *BatchAggregateProcessor[T]) OnTick(ctx context.Context) {
+	if processor.batchBlocks > 0 && processor.timeout() {
+		processor.write(ctx)
+	}
 }
 
 func (processor *BatchAggregateProcessor[T]) write(ctx context.Context) {
+	if processor.option.Pipeline {
+		// wait for the previous batch written to keep order
+		processor.wait(ctx)
+	}
+
+	logger := log.WithModule(ModuleName).WithFields(logrus.Fields{
+		"size":   processor.size,
+		"bytes":  processor.bytes,
+		"blocks": processor.batchBlocks,
+		"first":  processor.batchFirst,
+	})
+
+	batch := processor.batch
+
+	if !processor.option.Pipeline {
+		err := processor.writeBatch(ctx, processor, logger)
+
+		for _, v := range processor.processors {
+			v.BatchReset()
+		}
+
+		processor.reset()
+
+		if err != nil && !errors.Is(err, ctx.Err()) {
+			processor.writeOneByOne(ctx, batch)
+		}
+
+		return
+	}
+
+	ops := make([]Operation, 0, len(processor.processors))
+	for _, v := range processor.processors {
+		ops = append(ops, v.(PipelineBatchProcessor).BatchDetach(processor.option.CreateBatchSize))
+	}
+
+	processor.reset()
+
+	written := make(chan struct{})
+	processor.written = written
+
+	go func() {
+		defer close(written)
+
+		if err := processor.writeBatch(ctx, ComposeOperation(ops...), logger); err != nil && !errors.Is(err, ctx.Err()) {
+			processor.failed = &batch
+		}
+	}()
+}
+
+// writeBatch writes the batch into database till succeeded or context done, in which case ctx.Err() is returned.
+// Otherwise, it returns the error if dead letter policy should be applied, in which case the batch should be
+// written block by block to isolate the poison data.
+func (processor *BatchAggregateProcessor[T]) writeBatch(ctx context.Context, op Operation, logger *logrus.Entry) error {
 	start := time.Now()
 
-	processor.Write(ctx, processor)
+	err := processor.tryWrite(ctx, op)
+	if err != nil && errors.Is(err, ctx.Err()) {
+		logger.Debug("Aborted to write database in batch since context done")
+		return err
+	}
 
-	log.WithModule(ModuleName).WithFields(logrus.Fields{
-		"size":    processor.size,
-		"elapsed": time.Since(start),
-	}).Trace("Succeeded to write database in batch")
+	if err != nil {
+		logger.WithError(err).Warn("Failed to write database in batch, fall back to write block by block")
+		return err
+	}
 
-	// reset
-	processor.lastBatchTime = time.Now()
-	processor.size = 0
+	logger.WithField("elapsed", time.Since(start)).Trace("Succeeded to write database in batch")
 
+	return nil
+}
+
+// writeOneByOne writes the given batch into database block by block, so that only the poison data will be
+// handled by dead letter policy, along with the blockchain data persisted for replay.
+//
+// Note, processors should have no data in batch, which will be reset at first.
+func (processor *BatchAggregateProcessor[T]) writeOneByOne(ctx context.Context, batch batchData[T]) {
 	for _, v := range processor.processors {
 		v.BatchReset()
 	}
+
+	// roll back the in-memory states before the batch processed, e.g. checkpoint
+	for _, rollback := range batch.savepoints {
+		rollback()
+	}
+
+	for _, data := range batch.data {
+		for _, v := range processor.processors {
+			v.BatchProcess(data)
+		}
+
+		processor.WriteData(ctx, processor, data)
+
+		for _, v := range processor.processors {
+			v.BatchReset()
+		}
+
+		if ctxutil.IsDone(ctx) {
+			return
+		}
+	}
+}
+
+// reset resets the batch states for the next batch.
+func (processor *BatchAggregateProcessor[T]) reset() {
+	processor.lastBatchTime = time.Now()
+	processor.size = 0
+	processor.bytes = 0
+	processor.batchBlocks = 0
+	processor.batch = batchData[T]{}
+}
+
+// wait waits for the batch written in background if any. If failed, the batch will be written block by block,
+// and then the pending batch will be processed again, since the in-memory states of processors are rolled back.
+func (processor *BatchAggregateProcessor[T]) wait(ctx context.Context) {
+	if processor.written == nil {
+		return
+	}
+
+	<-processor.written
+	processor.written = nil
+
+	failed := processor.failed
+	if failed == nil {
+		return
+	}
+
+	processor.failed = nil
+
+	pending := processor.batch.data
+	lastBatchTime := processor.lastBatchTime
+
+	processor.writeOneByOne(ctx, *failed)
+
+	processor.reset()
+	processor.lastBatchTime = lastBatchTime
+
+	for _, data := range pending {
+		processor.add(data)
+	}
 }
 
 // Exec implements the Operation interface.
@@ -106,9 +350,34 @@ func (processor *BatchAggregateProcessor[T]) Exec(tx *gorm.DB) error {
 	return nil
 }
 
-// Close implements the process.CatchUpProcessor[T] interface.
+// OnCatchedUp implements the process.CatchUpProcessor[T] interface.
 func (processor *BatchAggregateProcessor[T]) OnCatchedUp(ctx context.Context) {
-	if processor.size > 0 {
+	if processor.batchBlocks > 0 {
 		processor.write(ctx)
 	}
+
+	processor.wait(ctx)
+}
+
+// Close implements the process.CloseProcessor interface, which waits for the batch written in background if any,
+// so that no database write is in flight once terminated, e.g. context done.
+//
+// Note, the pending batch that not written yet will be discarded.
+func (processor *BatchAggregateProcessor[T]) Close(ctx context.Context) {
+	if processor.batchBlocks > 0 {
+		log.WithModule(ModuleName).WithField("blocks", processor.batchBlocks).Debug("Discarded the pending batch")
+
+		for _, v := range processor.processors {
+			v.BatchReset()
+		}
+
+		// roll back the in-memory states if any, e.g. checkpoint
+		for _, rollback := range processor.batch.savepoints {
+			rollback()
+		}
+
+		processor.reset()
+	}
+
+	processor.wait(ctx)
 }
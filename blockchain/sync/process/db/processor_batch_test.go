@@ -0,0 +1,263 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Conflux-Chain/go-conflux-util/blockchain/sync/poll/testutil"
+	"github.com/Conflux-Chain/go-conflux-util/blockchain/sync/process"
+	"github.com/Conflux-Chain/go-conflux-util/store"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type batchTestBlock struct {
+	Number uint64 `gorm:"primaryKey"`
+	Hash   string
+}
+
+type batchTestProcessor struct {
+	batch []batchTestBlock
+}
+
+func (processor *batchTestProcessor) BatchProcess(data testutil.Data) int {
+	processor.batch = append(processor.batch, batchTestBlock{data.Number, data.Hash})
+	return len(processor.batch)
+}
+
+func (processor *batchTestProcessor) BatchExec(tx *gorm.DB, createBatchSize int) error {
+	return CreateInBatches(tx, processor.batch, createBatchSize)
+}
+
+func (processor *batchTestProcessor) BatchReset() {
+	processor.batch = nil
+}
+
+func newBatchTestDB(t *testing.T) *gorm.DB {
+	// file based database, since accessed in multiple goroutines
+	storeConfig := store.NewMemoryConfig()
+	storeConfig.Sqlite.Path = filepath.Join(t.TempDir(), "batch.db")
+	DB := storeConfig.MustOpenOrCreate()
+	assert.NoError(t, DB.AutoMigrate(&batchTestBlock{}))
+
+	return DB
+}
+
+func countBatchTestBlocks(t *testing.T, DB *gorm.DB) int64 {
+	var count int64
+	assert.NoError(t, DB.Model(&batchTestBlock{}).Count(&count).Error)
+	return count
+}
+
+func TestBatchAggregateProcessorFlush(t *testing.T) {
+	DB := newBatchTestDB(t)
+
+	processor := NewBatchAggregateProcessor[testutil.Data](BatchOption{
+		BatchTimeout:  50 * time.Millisecond,
+		FlushInterval: 10 * time.Millisecond,
+	}, DB, &batchTestProcessor{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	dataCh := make(chan testutil.Data, 2)
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go process.ProcessCatchUp(ctx, &wg, dataCh, processor)
+
+	dataCh <- testutil.Data{Number: 1, Hash: "hash"}
+	dataCh <- testutil.Data{Number: 2, Hash: "hash"}
+	assert.Equal(t, int64(0), countBatchTestBlocks(t, DB))
+
+	// written in background once batch timeout, even though no more data polled
+	assert.Eventually(t, func() bool {
+		return countBatchTestBlocks(t, DB) == 2
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	wg.Wait()
+}
+
+func TestBatchAggregateProcessorBytes(t *testing.T) {
+	DB := newBatchTestDB(t)
+
+	data := testutil.Data{Number: 1, Hash: "hash"}
+
+	processor := NewBatchAggregateProcessor[testutil.Data](BatchOption{
+		BatchTimeout: time.Hour,
+		BatchBytes:   2 * data.Size(),
+	}, DB, &batchTestProcessor{})
+
+	processor.Process(context.Background(), data)
+	assert.Equal(t, int64(0), countBatchTestBlocks(t, DB))
+
+	// written once max bytes reached
+	processor.Process(context.Background(), testutil.Data{Number: 2, Hash: "hash"})
+	assert.Equal(t, int64(2), countBatchTestBlocks(t, DB))
+}
+
+type pipelineTestProcessor struct {
+	batchTestProcessor
+	written [][]uint64    // block numbers of written batches in order
+	delay   time.Duration // to simulate slow database write
+}
+
+func (processor *pipelineTestProcessor) BatchDetach(createBatchSize int) Operation {
+	batch := processor.batch
+	processor.batch = nil
+
+	return OperationFunc(func(tx *gorm.DB) error {
+		time.Sleep(processor.delay)
+
+		var numbers []uint64
+		for _, v := range batch {
+			numbers = append(numbers, v.Number)
+		}
+
+		processor.written = append(processor.written, numbers)
+
+		return CreateInBatches(tx, batch, createBatchSize)
+	})
+}
+
+func TestBatchAggregateProcessorPipeline(t *testing.T) {
+	DB := newBatchTestDB(t)
+
+	batchProcessor := &pipelineTestProcessor{}
+
+	processor := NewBatchAggregateProcessor[testutil.Data](BatchOption{
+		BatchSize:    2,
+		BatchTimeout: time.Hour,
+		Pipeline:     true,
+	}, DB, batchProcessor)
+	assert.True(t, processor.option.Pipeline)
+
+	for i := uint64(1); i <= 5; i++ {
+		processor.Process(context.Background(), testutil.Data{Number: i, Hash: "hash"})
+	}
+
+	processor.OnCatchedUp(context.Background())
+
+	assert.Equal(t, [][]uint64{{1, 2}, {3, 4}, {5}}, batchProcessor.written)
+	assert.Equal(t, int64(5), countBatchTestBlocks(t, DB))
+
+	// pipeline disabled if not supported by any processor
+	processor = NewBatchAggregateProcessor[testutil.Data](BatchOption{Pipeline: true}, DB, &batchTestProcessor{})
+	assert.False(t, processor.option.Pipeline)
+}
+
+func TestBatchAggregateProcessorClose(t *testing.T) {
+	DB := newBatchTestDB(t)
+
+	batchProcessor := &pipelineTestProcessor{delay: 100 * time.Millisecond}
+
+	processor := NewBatchAggregateProcessor[testutil.Data](BatchOption{
+		BatchSize:    2,
+		BatchTimeout: time.Hour,
+		Pipeline:     true,
+	}, DB, batchProcessor)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	dataCh := make(chan testutil.Data)
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go process.ProcessCatchUp(ctx, &wg, dataCh, processor)
+
+	// the 1st batch written in background, and block 3 pending in batch
+	for i := uint64(1); i <= 3; i++ {
+		dataCh <- testutil.Data{Number: i, Hash: "hash"}
+	}
+
+	// terminated without OnCatchedUp, but the background write completed
+	cancel()
+	wg.Wait()
+
+	assert.Equal(t, [][]uint64{{1, 2}}, batchProcessor.written)
+	assert.Equal(t, int64(2), countBatchTestBlocks(t, DB))
+}
+
+type poisonTestProcessor struct {
+	batchTestProcessor
+	poison    uint64
+	processed int // number of processed blocks in memory, which should be rolled back once batch failed
+}
+
+func (processor *poisonTestProcessor) BatchProcess(data testutil.Data) int {
+	processor.processed++
+	return processor.batchTestProcessor.BatchProcess(data)
+}
+
+func (processor *poisonTestProcessor) BatchExec(tx *gorm.DB, createBatchSize int) error {
+	return processor.operation(processor.batch, createBatchSize).Exec(tx)
+}
+
+func (processor *poisonTestProcessor) BatchDetach(createBatchSize int) Operation {
+	batch := processor.batch
+	processor.batch = nil
+
+	return processor.operation(batch, createBatchSize)
+}
+
+func (processor *poisonTestProcessor) BatchSavepoint() func() {
+	processed := processor.processed
+
+	return func() {
+		processor.processed = processed
+	}
+}
+
+func (processor *poisonTestProcessor) operation(batch []batchTestBlock, createBatchSize int) Operation {
+	return OperationFunc(func(tx *gorm.DB) error {
+		for _, v := range batch {
+			if v.Number == processor.poison {
+				return NewPermanentError(errors.New("bad data"))
+			}
+		}
+
+		return CreateInBatches(tx, batch, createBatchSize)
+	})
+}
+
+func TestBatchAggregateProcessorDeadLetter(t *testing.T) {
+	for _, pipeline := range []bool{false, true} {
+		DB := newBatchTestDB(t)
+
+		batchProcessor := &poisonTestProcessor{poison: 2}
+
+		processor := NewBatchAggregateProcessor[testutil.Data](BatchOption{
+			Option: Option{
+				RetryInterval: time.Millisecond,
+				DeadLetter:    DeadLetterOption{Enabled: true, Name: "test", Skip: true},
+			},
+			BatchSize:    2,
+			BatchTimeout: time.Hour,
+			Pipeline:     pipeline,
+		}, DB, batchProcessor)
+		assert.Equal(t, pipeline, processor.option.Pipeline)
+
+		for i := uint64(1); i <= 5; i++ {
+			processor.Process(context.Background(), testutil.Data{Number: i, Hash: "hash"})
+		}
+
+		processor.OnCatchedUp(context.Background())
+
+		// failed batch written block by block, and only the poison block skipped
+		var blocks []batchTestBlock
+		assert.NoError(t, DB.Order("number").Find(&blocks).Error)
+		assert.Equal(t, []batchTestBlock{{1, "hash"}, {3, "hash"}, {4, "hash"}, {5, "hash"}}, blocks)
+
+		var letters []DeadLetter
+		assert.NoError(t, DB.Find(&letters).Error)
+		assert.Equal(t, 1, len(letters))
+		assert.Equal(t, uint64(2), letters[0].BlockNumber)
+		assert.Equal(t, 1, letters[0].Blocks)
+		assert.Equal(t, `{"Number":2,"Hash":"hash","ParentHash":""}`, letters[0].Payload)
+
+		// in-memory states rolled back before written block by block
+		assert.Equal(t, 5, batchProcessor.processed)
+	}
+}
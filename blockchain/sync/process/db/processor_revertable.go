@@ -6,6 +6,8 @@ import (
 
 	"github.com/Conflux-Chain/go-conflux-util/blockchain/sync/poll"
 	"github.com/Conflux-Chain/go-conflux-util/log"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
 
@@ -17,6 +19,22 @@ type RevertableProcessor[T any] interface {
 	Revert(data T) Operation
 }
 
+// RewindableProcessor is optionally implemented by RevertableProcessor[T] to revert data precisely with the
+// rewind event, which contains the fork block and all orphaned blocks.
+type RewindableProcessor interface {
+
+	// Rewind deletes data from database of all orphaned blocks.
+	Rewind(rewind poll.Rewind) Operation
+}
+
+// RangeRevertableProcessor is optionally implemented by RevertableProcessor[T] to delete data of a block range,
+// so that the block range could be re-indexed without reverting the subsequent blocks.
+type RangeRevertableProcessor interface {
+
+	// RevertRange deletes data from database of blocks in range [fromBlockNumber, toBlockNumber].
+	RevertRange(fromBlockNumber, toBlockNumber uint64) Operation
+}
+
 // RevertableAggregateProcessor aggregates multiple processor to process blockchain data in batch,
 // and supports to process the reverted data when chain reorg happened.
 type RevertableAggregateProcessor[T any] struct {
@@ -45,19 +63,57 @@ func NewRevertableAggregateProcessor[T any](option Option, db *gorm.DB, processo
 }
 
 // Process implements the process.Processor[poll.Revertable[T]] interface.
+//
+// If chain reorg happened, the orphaned data will be reverted along with the new data processed in one transaction.
+// Once the new data skipped by dead letter policy, the orphaned data will still be reverted in a separate transaction.
 func (processor *RevertableAggregateProcessor[T]) Process(ctx context.Context, data poll.Revertable[T]) {
-	if data.Reverted {
-		var ops []Operation
+	if !data.Reverted {
+		processor.AggregateProcessor.Process(ctx, data.Data)
+		return
+	}
+
+	var ops []Operation
 
-		for _, v := range processor.processors {
-			op := v.Revert(data.Data)
-			ops = append(ops, op)
+	for _, v := range processor.processors {
+		if rewindable, ok := v.(RewindableProcessor); ok && data.Rewind != nil {
+			ops = append(ops, rewindable.Rewind(*data.Rewind))
+		} else {
+			ops = append(ops, v.Revert(data.Data))
 		}
+	}
 
-		processor.Write(ctx, ComposeOperation(ops...))
+	revert := ComposeOperation(ops...)
 
-		log.WithModule(ModuleName).Debug("Succeeded to process reverted blockchain data")
+	err := processor.tryWrite(ctx, ComposeOperation(revert, processor.operation(data.Data)))
+	if err != nil && errors.Is(err, ctx.Err()) {
+		return
 	}
 
-	processor.AggregateProcessor.Process(ctx, data.Data)
+	// The in-memory states (e.g. checkpoint) have been reverted, so the orphaned data should be deleted from
+	// database anyway, and only the new data is skipped.
+	if err != nil && processor.option.DeadLetter.Skip {
+		revertErr := processor.tryWrite(ctx, revert)
+		if revertErr != nil && errors.Is(revertErr, ctx.Err()) {
+			return
+		}
+
+		if revertErr != nil {
+			err = revertErr
+		}
+	}
+
+	if err != nil {
+		processor.deadLetter(ctx, newDeadLetter(data.Data), err)
+	}
+
+	processor.blocks.Mark(1)
+
+	if data.Rewind != nil {
+		log.WithModule(ModuleName).WithFields(logrus.Fields{
+			"fork":     data.Rewind.ForkBlockNumber,
+			"orphaned": len(data.Rewind.Orphaned),
+		}).Debug("Succeeded to process reverted blockchain data")
+	} else {
+		log.WithModule(ModuleName).Debug("Succeeded to process reverted blockchain data")
+	}
 }
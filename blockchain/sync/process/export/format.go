@@ -0,0 +1,153 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"maps"
+	"slices"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/pkg/errors"
+)
+
+// Format defines how to write rows into files, e.g. JSON lines or Parquet.
+type Format interface {
+	// Extension returns the file extension without dot, e.g. "jsonl".
+	Extension() string
+
+	// NewWriter returns a row writer to write into the given underlying writer.
+	NewWriter(w io.Writer) RowWriter
+}
+
+// RowWriter writes rows into file in a specific format.
+type RowWriter interface {
+	// Write writes the given row.
+	Write(row any) error
+
+	// Close flushes all buffered rows, but not closes the underlying writer.
+	Close() error
+}
+
+// JSONLFormat writes each row in JSON format in a separate line.
+type JSONLFormat struct{}
+
+// Extension implements the Format interface.
+func (JSONLFormat) Extension() string {
+	return "jsonl"
+}
+
+// NewWriter implements the Format interface.
+func (JSONLFormat) NewWriter(w io.Writer) RowWriter {
+	buf := bufio.NewWriter(w)
+
+	return &jsonlWriter{buf, json.NewEncoder(buf)}
+}
+
+type jsonlWriter struct {
+	buf     *bufio.Writer
+	encoder *json.Encoder
+}
+
+func (w *jsonlWriter) Write(row any) error {
+	return w.encoder.Encode(row)
+}
+
+func (w *jsonlWriter) Close() error {
+	return w.buf.Flush()
+}
+
+// ParquetFormat writes rows in Apache Parquet format, in which each top-level field of the row in JSON format is
+// written as an optional string column, and nested objects or arrays are written in JSON format.
+//
+// Note, rows are buffered in memory until closed, since the schema is derived from all rows in file. So, please
+// use a smaller partition size if too many rows in a partition.
+type ParquetFormat struct{}
+
+// Extension implements the Format interface.
+func (ParquetFormat) Extension() string {
+	return "parquet"
+}
+
+// NewWriter implements the Format interface.
+func (ParquetFormat) NewWriter(w io.Writer) RowWriter {
+	return &parquetWriter{w: w}
+}
+
+type parquetWriter struct {
+	w    io.Writer
+	rows []map[string]json.RawMessage
+}
+
+func (w *parquetWriter) Write(row any) error {
+	data, err := json.Marshal(row)
+	if err != nil {
+		return errors.WithMessage(err, "Failed to marshal row in JSON format")
+	}
+
+	var fields map[string]json.RawMessage
+	if err = json.Unmarshal(data, &fields); err != nil {
+		return errors.WithMessage(err, "Row should be a JSON object")
+	}
+
+	w.rows = append(w.rows, fields)
+
+	return nil
+}
+
+func (w *parquetWriter) Close() error {
+	names := make(map[string]struct{})
+	for _, row := range w.rows {
+		for name := range row {
+			names[name] = struct{}{}
+		}
+	}
+
+	// columns are sorted by name in parquet group
+	columns := slices.Sorted(maps.Keys(names))
+
+	group := make(parquet.Group, len(columns))
+	for _, v := range columns {
+		group[v] = parquet.Optional(parquet.String())
+	}
+
+	writer := parquet.NewWriter(w.w, parquet.NewSchema("row", group))
+
+	rows := make([]parquet.Row, 0, len(w.rows))
+	for _, fields := range w.rows {
+		row := make(parquet.Row, 0, len(columns))
+
+		for i, name := range columns {
+			if value, ok := parquetValue(fields[name]); ok {
+				row = append(row, parquet.ValueOf(value).Level(0, 1, i))
+			} else {
+				row = append(row, parquet.NullValue().Level(0, 0, i))
+			}
+		}
+
+		rows = append(rows, row)
+	}
+
+	if _, err := writer.WriteRows(rows); err != nil {
+		return errors.WithMessage(err, "Failed to write rows in parquet format")
+	}
+
+	w.rows = nil
+
+	return writer.Close()
+}
+
+// parquetValue returns the string value of the given JSON field, and returns false if absent or null.
+func parquetValue(field json.RawMessage) (string, bool) {
+	if len(field) == 0 || string(field) == "null" {
+		return "", false
+	}
+
+	var value string
+	if err := json.Unmarshal(field, &value); err == nil {
+		return value, true
+	}
+
+	// number, bool, object or array
+	return string(field), true
+}
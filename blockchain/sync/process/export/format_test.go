@@ -0,0 +1,39 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/assert"
+)
+
+type parquetTestRow struct {
+	Hash   *string `parquet:"hash,optional"`
+	Number *string `parquet:"number,optional"`
+	Logs   *string `parquet:"logs,optional"`
+}
+
+func TestParquetFormat(t *testing.T) {
+	var buf bytes.Buffer
+
+	writer := ParquetFormat{}.NewWriter(&buf)
+	assert.NoError(t, writer.Write(map[string]any{"hash": "0x1", "number": 1, "logs": []int{1, 2}}))
+	assert.NoError(t, writer.Write(map[string]any{"hash": "0x2", "logs": nil}))
+	assert.Error(t, writer.Write("not object"))
+	assert.NoError(t, writer.Close())
+
+	rows, err := parquet.Read[parquetTestRow](bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(rows))
+
+	// string unquoted, and others in JSON format
+	assert.Equal(t, "0x1", *rows[0].Hash)
+	assert.Equal(t, "1", *rows[0].Number)
+	assert.Equal(t, "[1,2]", *rows[0].Logs)
+
+	// absent or null
+	assert.Equal(t, "0x2", *rows[1].Hash)
+	assert.Nil(t, rows[1].Number)
+	assert.Nil(t, rows[1].Logs)
+}
@@ -0,0 +1,72 @@
+package export
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+const manifestFileName = "manifest.json"
+
+// Partition is the exported files of a block range.
+type Partition struct {
+	From      uint64            `json:"from"`
+	To        uint64            `json:"to"`
+	BlockHash string            `json:"blockHash"` // hash of the last block
+	Files     map[string]string `json:"files"`     // file name => SHA256 hash of file
+}
+
+// Manifest records all the exported partitions in sequence, so that export could be resumed.
+type Manifest struct {
+	Partitions []Partition `json:"partitions"`
+}
+
+// LoadManifest loads the manifest from the given export directory, and returns an empty manifest if not found.
+func LoadManifest(dir string) (*Manifest, error) {
+	content, err := os.ReadFile(filepath.Join(dir, manifestFileName))
+	if os.IsNotExist(err) {
+		return &Manifest{}, nil
+	}
+
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to read manifest file")
+	}
+
+	var manifest Manifest
+	if err = json.Unmarshal(content, &manifest); err != nil {
+		return nil, errors.WithMessage(err, "Failed to unmarshal manifest")
+	}
+
+	return &manifest, nil
+}
+
+// save writes the manifest into the given export directory atomically.
+func (manifest *Manifest) save(dir string) error {
+	content, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return errors.WithMessage(err, "Failed to marshal manifest")
+	}
+
+	path := filepath.Join(dir, manifestFileName)
+
+	if err = os.WriteFile(path+".tmp", content, 0644); err != nil {
+		return errors.WithMessage(err, "Failed to write temp manifest file")
+	}
+
+	if err = os.Rename(path+".tmp", path); err != nil {
+		return errors.WithMessage(err, "Failed to rename temp manifest file")
+	}
+
+	return nil
+}
+
+// NextBlockNumber returns the next block number to export, or false if no partition exported yet.
+func (manifest *Manifest) NextBlockNumber() (uint64, bool) {
+	if len(manifest.Partitions) == 0 {
+		return 0, false
+	}
+
+	return manifest.Partitions[len(manifest.Partitions)-1].To + 1, true
+}
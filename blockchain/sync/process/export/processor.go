@@ -0,0 +1,283 @@
+package export
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/Conflux-Chain/go-conflux-util/log"
+	"github.com/mcuadros/go-defaults"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+var ModuleName = "sync.process.export"
+
+type Option struct {
+	// Dir is the directory to export files and manifest.
+	Dir string `default:"export"`
+
+	// PartitionSize is the number of blocks in a partition, which is aligned by block number.
+	PartitionSize uint64 `default:"10000"`
+
+	// Format is the file format, e.g. JSONLFormat (by default) or ParquetFormat.
+	Format Format
+}
+
+// Processor exports blockchain data into files that partitioned by block range, which implements the
+// process.CatchUpProcessor[T] interface.
+//
+// Each partition contains a file for each table, which is written into a temp file and renamed once the
+// partition completed. The completed partitions are recorded in manifest, so that export could be resumed
+// from the next block of the last partition.
+type Processor[T any] struct {
+	option          Option
+	extract         ExtractFunc[T]
+	manifest        *Manifest
+	nextBlockNumber uint64
+	partition       *partitionWriter // nil if no data to export in current partition
+}
+
+// NewProcessor creates a new export processor, which starts from the given block number if no partition
+// exported yet. Otherwise, it resumes from the manifest.
+func NewProcessor[T any](extract ExtractFunc[T], nextBlockNumber uint64, option ...Option) (*Processor[T], error) {
+	var opt Option
+	if len(option) > 0 {
+		opt = option[0]
+	}
+
+	defaults.SetDefaults(&opt)
+
+	if opt.Format == nil {
+		opt.Format = JSONLFormat{}
+	}
+
+	if err := os.MkdirAll(opt.Dir, 0755); err != nil {
+		return nil, errors.WithMessage(err, "Failed to create export directory")
+	}
+
+	manifest, err := LoadManifest(opt.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if next, ok := manifest.NextBlockNumber(); ok {
+		nextBlockNumber = next
+	}
+
+	return &Processor[T]{
+		option:          opt,
+		extract:         extract,
+		manifest:        manifest,
+		nextBlockNumber: nextBlockNumber,
+	}, nil
+}
+
+// NextBlockNumber returns the next block number to export.
+func (processor *Processor[T]) NextBlockNumber() uint64 {
+	return processor.nextBlockNumber
+}
+
+// Manifest returns the manifest of exported partitions.
+func (processor *Processor[T]) Manifest() Manifest {
+	return *processor.manifest
+}
+
+// Process implements the process.CatchUpProcessor[T] interface, which terminates the process if failed to export.
+// Please use Export instead to handle errors.
+func (processor *Processor[T]) Process(ctx context.Context, data T) {
+	if err := processor.Export(data); err != nil {
+		log.WithModule(ModuleName).WithError(err).Fatal("Failed to export blockchain data")
+	}
+}
+
+// OnCatchedUp implements the process.CatchUpProcessor[T] interface, which commits the incomplete partition
+// if any, so that export could be resumed from the next block later. Please use Flush instead to handle errors.
+func (processor *Processor[T]) OnCatchedUp(ctx context.Context) {
+	if err := processor.Flush(); err != nil {
+		log.WithModule(ModuleName).WithError(err).Fatal("Failed to commit export partition")
+	}
+}
+
+// Export exports the given blockchain data, which should be the next block to export, and commits the partition
+// once completed.
+func (processor *Processor[T]) Export(data T) error {
+	rows := processor.extract(data)
+
+	if rows.BlockNumber != processor.nextBlockNumber {
+		return errors.Errorf("Block number mismatch to export, expected = %v, actual = %v",
+			processor.nextBlockNumber, rows.BlockNumber)
+	}
+
+	if err := processor.write(rows); err != nil {
+		return errors.WithMessagef(err, "Failed to export blockchain data of block %v", rows.BlockNumber)
+	}
+
+	processor.nextBlockNumber++
+
+	// partition completed
+	if processor.nextBlockNumber%processor.option.PartitionSize == 0 {
+		if err := processor.commit(); err != nil {
+			return errors.WithMessage(err, "Failed to commit export partition")
+		}
+	}
+
+	return nil
+}
+
+// Flush commits the incomplete partition if any, so that export could be resumed from the next block later.
+func (processor *Processor[T]) Flush() error {
+	if err := processor.commit(); err != nil {
+		return errors.WithMessage(err, "Failed to commit export partition")
+	}
+
+	return nil
+}
+
+func (processor *Processor[T]) write(rows Rows) error {
+	if processor.partition == nil {
+		processor.partition = newPartitionWriter(processor.option, rows.BlockNumber)
+	}
+
+	return processor.partition.write(rows)
+}
+
+// commit closes all files of current partition, and records the partition in manifest.
+func (processor *Processor[T]) commit() error {
+	if processor.partition == nil {
+		return nil
+	}
+
+	partition, err := processor.partition.close()
+	if err != nil {
+		return err
+	}
+
+	processor.manifest.Partitions = append(processor.manifest.Partitions, partition)
+	if err = processor.manifest.save(processor.option.Dir); err != nil {
+		return err
+	}
+
+	processor.partition = nil
+
+	log.WithModule(ModuleName).WithFields(logrus.Fields{
+		"from": partition.From,
+		"to":   partition.To,
+	}).Debug("Succeeded to export partition")
+
+	return nil
+}
+
+type tableFile struct {
+	file   *os.File
+	writer RowWriter
+	hash   hash.Hash
+}
+
+// partitionWriter writes rows of tables into temp files of a partition.
+type partitionWriter struct {
+	option    Option
+	from      uint64
+	to        uint64
+	blockHash string
+	tables    map[string]*tableFile
+}
+
+func newPartitionWriter(option Option, from uint64) *partitionWriter {
+	return &partitionWriter{
+		option: option,
+		from:   from,
+		tables: make(map[string]*tableFile),
+	}
+}
+
+func (writer *partitionWriter) write(rows Rows) error {
+	for table, tableRows := range rows.Tables {
+		file, err := writer.open(table)
+		if err != nil {
+			return err
+		}
+
+		for _, v := range tableRows {
+			if err = file.writer.Write(v); err != nil {
+				return errors.WithMessagef(err, "Failed to write row into table %v", table)
+			}
+		}
+	}
+
+	writer.to = rows.BlockNumber
+	writer.blockHash = rows.BlockHash
+
+	return nil
+}
+
+// open opens the temp file of given table, which will be truncated if already exists.
+func (writer *partitionWriter) open(table string) (*tableFile, error) {
+	if file, ok := writer.tables[table]; ok {
+		return file, nil
+	}
+
+	path := writer.path(table) + ".tmp"
+
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "Failed to create file %v", path)
+	}
+
+	hash := sha256.New()
+	writer.tables[table] = &tableFile{
+		file:   file,
+		writer: writer.option.Format.NewWriter(io.MultiWriter(file, hash)),
+		hash:   hash,
+	}
+
+	return writer.tables[table], nil
+}
+
+// path returns the file path of the given table, which contains the block range after partition completed.
+func (writer *partitionWriter) path(table string, to ...uint64) string {
+	name := fmt.Sprintf("%v_%v", table, writer.from)
+	if len(to) > 0 {
+		name = fmt.Sprintf("%v_%v", name, to[0])
+	}
+
+	return filepath.Join(writer.option.Dir, fmt.Sprintf("%v.%v", name, writer.option.Format.Extension()))
+}
+
+// close closes all temp files, and renames to the final files with block range.
+func (writer *partitionWriter) close() (Partition, error) {
+	partition := Partition{
+		From:      writer.from,
+		To:        writer.to,
+		BlockHash: writer.blockHash,
+		Files:     make(map[string]string),
+	}
+
+	for table, v := range writer.tables {
+		if err := v.writer.Close(); err != nil {
+			return Partition{}, errors.WithMessagef(err, "Failed to flush file of table %v", table)
+		}
+
+		if err := v.file.Sync(); err != nil {
+			return Partition{}, errors.WithMessagef(err, "Failed to sync file of table %v", table)
+		}
+
+		if err := v.file.Close(); err != nil {
+			return Partition{}, errors.WithMessagef(err, "Failed to close file of table %v", table)
+		}
+
+		path := writer.path(table, writer.to)
+		if err := os.Rename(writer.path(table)+".tmp", path); err != nil {
+			return Partition{}, errors.WithMessagef(err, "Failed to rename file of table %v", table)
+		}
+
+		partition.Files[filepath.Base(path)] = hex.EncodeToString(v.hash.Sum(nil))
+	}
+
+	return partition, nil
+}
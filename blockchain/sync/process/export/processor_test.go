@@ -0,0 +1,58 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Conflux-Chain/go-conflux-util/blockchain/sync/poll/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func extractTestData(data testutil.Data) Rows {
+	return Rows{
+		BlockNumber: data.Number,
+		BlockHash:   data.Hash,
+		Tables: map[string][]any{
+			TableBlocks: {data},
+		},
+	}
+}
+
+func TestProcessor(t *testing.T) {
+	dir := t.TempDir()
+
+	processor, err := NewProcessor(extractTestData, 2, Option{Dir: dir, PartitionSize: 3})
+	assert.NoError(t, err)
+
+	for i := uint64(2); i <= 7; i++ {
+		processor.Process(context.Background(), testutil.Data{Number: i, Hash: fmt.Sprintf("DataHash-%v", i)})
+	}
+
+	processor.OnCatchedUp(context.Background())
+
+	manifest, err := LoadManifest(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(manifest.Partitions))
+
+	// partitions aligned by block number
+	for i, v := range [][2]uint64{{2, 2}, {3, 5}, {6, 7}} {
+		assert.Equal(t, v[0], manifest.Partitions[i].From)
+		assert.Equal(t, v[1], manifest.Partitions[i].To)
+	}
+
+	assert.Equal(t, "DataHash-7", manifest.Partitions[2].BlockHash)
+	assert.Contains(t, manifest.Partitions[1].Files, "blocks_3_5.jsonl")
+
+	content, err := os.ReadFile(filepath.Join(dir, "blocks_3_5.jsonl"))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, strings.Count(string(content), "\n"))
+
+	// resume from manifest
+	processor, err = NewProcessor(extractTestData, 2, Option{Dir: dir, PartitionSize: 3})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(8), processor.NextBlockNumber())
+}
@@ -0,0 +1,102 @@
+package export
+
+import (
+	"github.com/Conflux-Chain/go-conflux-util/blockchain/sync/core"
+	"github.com/Conflux-Chain/go-conflux-util/blockchain/sync/evm"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/openweb3/web3go/types"
+)
+
+const (
+	TableBlocks       = "blocks"
+	TableTransactions = "transactions"
+	TableReceipts     = "receipts"
+	TableTraces       = "traces"
+)
+
+// Rows is the rows of tables extracted from blockchain data of a block.
+type Rows struct {
+	BlockNumber uint64
+	BlockHash   string
+	Tables      map[string][]any // table name => rows
+}
+
+// ExtractFunc extracts rows of tables from blockchain data.
+type ExtractFunc[T any] func(data T) Rows
+
+// ExtractBlockData extracts blocks, transactions, receipts and traces from eSpace block data, in which
+// the block only contains transaction hashes.
+func ExtractBlockData(data evm.BlockData) Rows {
+	txs := data.Block.Transactions.Transactions()
+
+	hashes := make([]common.Hash, 0, len(txs))
+	for _, v := range txs {
+		hashes = append(hashes, v.Hash)
+	}
+
+	block := *data.Block
+	block.Transactions = *types.NewTxOrHashListByHashes(hashes)
+
+	tables := map[string][]any{
+		TableBlocks:       {block},
+		TableTransactions: toRows(txs),
+	}
+
+	if data.Receipts != nil {
+		tables[TableReceipts] = toRows(data.Receipts)
+	}
+
+	if data.Traces != nil {
+		tables[TableTraces] = toRows(data.Traces)
+	}
+
+	return Rows{
+		BlockNumber: data.Block.Number.Uint64(),
+		BlockHash:   data.Block.Hash.Hex(),
+		Tables:      tables,
+	}
+}
+
+// ExtractEpochData extracts blocks, transactions, receipts and traces from core space epoch data, in which
+// the block only contains the block header.
+func ExtractEpochData(data core.EpochData) Rows {
+	tables := map[string][]any{
+		TableBlocks:       nil,
+		TableTransactions: nil,
+	}
+
+	for _, v := range data.Blocks {
+		tables[TableBlocks] = append(tables[TableBlocks], v.BlockHeader)
+		tables[TableTransactions] = append(tables[TableTransactions], toRows(v.Transactions)...)
+	}
+
+	if data.Receipts != nil {
+		tables[TableReceipts] = []any{}
+
+		for _, v := range data.Receipts {
+			tables[TableReceipts] = append(tables[TableReceipts], toRows(v)...)
+		}
+	}
+
+	if data.Traces != nil {
+		tables[TableTraces] = toRows(data.Traces.CfxTraces)
+	}
+
+	pivotBlock := data.Blocks[len(data.Blocks)-1]
+
+	return Rows{
+		BlockNumber: pivotBlock.EpochNumber.ToInt().Uint64(),
+		BlockHash:   pivotBlock.Hash.String(),
+		Tables:      tables,
+	}
+}
+
+func toRows[T any](values []T) []any {
+	rows := make([]any, 0, len(values))
+
+	for _, v := range values {
+		rows = append(rows, v)
+	}
+
+	return rows
+}
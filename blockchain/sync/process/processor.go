@@ -3,6 +3,7 @@ package process
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/Conflux-Chain/go-conflux-util/ctxutil"
 )
@@ -20,20 +21,48 @@ type CatchUpProcessor[T any] interface {
 	OnCatchedUp(ctx context.Context)
 }
 
+// TickProcessor is optionally implemented by Processor[T] to execute periodically in the same goroutine of Process,
+// e.g. write the pending batch into database when no more data polled for a while.
+type TickProcessor interface {
+	// TickInterval returns the interval to execute OnTick.
+	TickInterval() time.Duration
+
+	// OnTick is executed periodically, even though no data received.
+	OnTick(ctx context.Context)
+}
+
+// CloseProcessor is optionally implemented by Processor[T] to release resources once terminated, even though the
+// context done, e.g. wait for the database write in background.
+type CloseProcessor interface {
+	// Close is executed in the same goroutine of Process once terminated.
+	Close(ctx context.Context)
+}
+
 // Process retrieves data from the given channel and processes data with given processor.
 //
 // Generally, it will be executed in a separate goroutine, and terminate if given context done or channel closed.
 func Process[T any](ctx context.Context, wg *sync.WaitGroup, dataCh <-chan T, processor Processor[T]) {
 	defer wg.Done()
+	defer closeProcessor(ctx, processor)
 
 	process(ctx, dataCh, processor)
 }
 
 func process[T any](ctx context.Context, dataCh <-chan T, processor Processor[T]) bool {
+	// nil channel if not implemented, which blocks forever
+	var tickCh <-chan time.Time
+	if tickProcessor, ok := processor.(TickProcessor); ok {
+		ticker := time.NewTicker(tickProcessor.TickInterval())
+		defer ticker.Stop()
+		tickCh = ticker.C
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
 			return false
+		case <-tickCh:
+			processor.(TickProcessor).OnTick(ctx)
 		case data, ok := <-dataCh:
 			if !ok {
 				return true
@@ -53,8 +82,15 @@ func process[T any](ctx context.Context, dataCh <-chan T, processor Processor[T]
 // ProcessCatchUp processes the polled blockchain data from given data channel till the latest finalized block processed.
 func ProcessCatchUp[T any](ctx context.Context, wg *sync.WaitGroup, dataCh <-chan T, processor CatchUpProcessor[T]) {
 	defer wg.Done()
+	defer closeProcessor(ctx, processor)
 
 	if process(ctx, dataCh, processor) {
 		processor.OnCatchedUp(ctx)
 	}
 }
+
+func closeProcessor[T any](ctx context.Context, processor Processor[T]) {
+	if closer, ok := processor.(CloseProcessor); ok {
+		closer.Close(ctx)
+	}
+}
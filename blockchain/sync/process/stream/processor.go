@@ -0,0 +1,123 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Conflux-Chain/go-conflux-util/blockchain/sync/poll"
+	"github.com/Conflux-Chain/go-conflux-util/ctxutil"
+	"github.com/Conflux-Chain/go-conflux-util/health"
+	"github.com/Conflux-Chain/go-conflux-util/log"
+	"github.com/mcuadros/go-defaults"
+)
+
+type Option struct {
+	RetryInterval time.Duration `default:"3s"`
+
+	Health health.TimedCounterConfig
+}
+
+// Processor publishes the serialized blockchain data in order, and retries till succeeded to guarantee
+// at least once delivery.
+type Processor[T any] struct {
+	option    Option
+	publisher Publisher
+	encode    EncodeFunc[T]
+	health    *health.TimedCounter
+}
+
+func NewProcessor[T any](publisher Publisher, encode EncodeFunc[T], option ...Option) *Processor[T] {
+	var opt Option
+	if len(option) > 0 {
+		opt = option[0]
+	}
+
+	defaults.SetDefaults(&opt)
+
+	return &Processor[T]{
+		option:    opt,
+		publisher: publisher,
+		encode:    encode,
+		health:    health.NewTimedCounter(opt.Health),
+	}
+}
+
+// Process implements the process.Processor[T] interface.
+func (processor *Processor[T]) Process(ctx context.Context, data T) {
+	processor.publish(ctx, processor.mustEncode(data))
+}
+
+func (processor *Processor[T]) mustEncode(data T) Message {
+	msg, err := processor.encode(data)
+	if err != nil {
+		log.WithModule(ModuleName).WithError(err).Fatal("Failed to encode blockchain data")
+	}
+
+	return msg
+}
+
+// publish publishes the given messages. If failed, it will try again till succeeded.
+func (processor *Processor[T]) publish(ctx context.Context, messages ...Message) {
+	for {
+		err := processor.publisher.Publish(ctx, messages...)
+
+		processor.health.LogOnError(err, "Publish blockchain data")
+
+		if err == nil {
+			return
+		}
+
+		log.WithModule(ModuleName).WithError(err).Debug("Failed to publish messages")
+
+		if err = ctxutil.Sleep(ctx, processor.option.RetryInterval); err != nil {
+			return
+		}
+	}
+}
+
+// RevertableProcessor publishes the serialized blockchain data along with the chain reorg event, so that
+// consumers could revert the orphaned blocks.
+type RevertableProcessor[T any] struct {
+	*Processor[T]
+}
+
+func NewRevertableProcessor[T any](publisher Publisher, encode EncodeFunc[T], option ...Option) *RevertableProcessor[T] {
+	return &RevertableProcessor[T]{
+		Processor: NewProcessor(publisher, encode, option...),
+	}
+}
+
+// Process implements the process.Processor[poll.Revertable[T]] interface.
+//
+// If chain reorg happened, a rewind message will be published ahead of the new data.
+func (processor *RevertableProcessor[T]) Process(ctx context.Context, data poll.Revertable[T]) {
+	msg := processor.mustEncode(data.Data)
+
+	if data.Rewind == nil {
+		processor.publish(ctx, msg)
+		return
+	}
+
+	value, err := json.Marshal(data.Rewind)
+	if err != nil {
+		log.WithModule(ModuleName).WithError(err).Fatal("Failed to marshal rewind event")
+	}
+
+	processor.publish(ctx, Message{
+		Key:         rewindKey(*data.Rewind),
+		Type:        MessageTypeRewind,
+		BlockNumber: data.Rewind.ForkBlockNumber,
+		Value:       value,
+	}, msg)
+}
+
+// rewindKey returns a unique key for the given chain reorg.
+func rewindKey(rewind poll.Rewind) string {
+	if len(rewind.Orphaned) == 0 {
+		return fmt.Sprintf("rewind:%v", rewind.ForkBlockHash)
+	}
+
+	return fmt.Sprintf("rewind:%v:%v", rewind.ForkBlockHash, rewind.Orphaned[len(rewind.Orphaned)-1].Hash)
+}
@@ -0,0 +1,182 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Conflux-Chain/go-conflux-util/blockchain/sync/poll"
+	"github.com/Conflux-Chain/go-conflux-util/blockchain/sync/poll/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func encodeTestData(data testutil.Data) (Message, error) {
+	return Message{
+		Key:         data.Hash,
+		Type:        MessageTypeBlock,
+		BlockNumber: data.Number,
+		Value:       []byte(data.ParentHash),
+	}, nil
+}
+
+func TestRevertableProcessor(t *testing.T) {
+	publisher := NewMemoryPublisher()
+	processor := NewRevertableProcessor(publisher, encodeTestData)
+
+	rewind := poll.Rewind{
+		ForkBlockNumber: 5,
+		ForkBlockHash:   "DataHash-5",
+		Orphaned:        []poll.OrphanedBlock{{Number: 6, Hash: "DataHash-6"}},
+	}
+
+	processor.Process(context.Background(), poll.Revertable[testutil.Data]{
+		Data: testutil.Data{Number: 6, Hash: "DataHash-6", ParentHash: "DataHash-5"},
+	})
+	processor.Process(context.Background(), poll.Revertable[testutil.Data]{
+		Data:     testutil.Data{Number: 6, Hash: "DataHash-66", ParentHash: "DataHash-5"},
+		Reverted: true,
+		Rewind:   &rewind,
+	})
+
+	// published again, e.g. retry after restarted
+	processor.Process(context.Background(), poll.Revertable[testutil.Data]{
+		Data: testutil.Data{Number: 6, Hash: "DataHash-66", ParentHash: "DataHash-5"},
+	})
+
+	messages := publisher.Messages()
+	assert.Equal(t, 3, len(messages))
+
+	assert.Equal(t, MessageTypeBlock, messages[0].Type)
+	assert.Equal(t, "DataHash-6", messages[0].Key)
+
+	assert.Equal(t, MessageTypeRewind, messages[1].Type)
+	assert.Equal(t, "rewind:DataHash-5:DataHash-6", messages[1].Key)
+	assert.Equal(t, uint64(5), messages[1].BlockNumber)
+
+	assert.Equal(t, MessageTypeBlock, messages[2].Type)
+	assert.Equal(t, "DataHash-66", messages[2].Key)
+}
+
+func TestFilePublisher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocks.log")
+
+	publisher, err := NewFilePublisher(path)
+	assert.NoError(t, err)
+
+	processor := NewProcessor(publisher, encodeTestData)
+	processor.Process(context.Background(), testutil.Data{Number: 1, Hash: "DataHash-1", ParentHash: "DataHash-0"})
+	processor.Process(context.Background(), testutil.Data{Number: 2, Hash: "DataHash-2", ParentHash: "DataHash-1"})
+	assert.NoError(t, publisher.Close())
+
+	// append an incomplete line
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	assert.NoError(t, err)
+	_, err = file.WriteString(`{"key":"DataHash-3"`)
+	assert.NoError(t, err)
+	assert.NoError(t, file.Close())
+
+	// reopen to publish the last message again
+	publisher, err = NewFilePublisher(path)
+	assert.NoError(t, err)
+
+	processor = NewProcessor(publisher, encodeTestData)
+	processor.Process(context.Background(), testutil.Data{Number: 2, Hash: "DataHash-2", ParentHash: "DataHash-1"})
+	processor.Process(context.Background(), testutil.Data{Number: 3, Hash: "DataHash-3", ParentHash: "DataHash-2"})
+	assert.NoError(t, publisher.Close())
+
+	messages, err := ReadFileLog(path)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(messages))
+
+	for i, v := range messages {
+		assert.Equal(t, uint64(i+1), v.BlockNumber)
+	}
+
+	assert.Equal(t, []byte("DataHash-2"), messages[2].Value)
+}
+
+func newTestData(blockNumber uint64) testutil.Data {
+	return testutil.Data{
+		Number:     blockNumber,
+		Hash:       fmt.Sprintf("DataHash-%v", blockNumber),
+		ParentHash: fmt.Sprintf("DataHash-%v", blockNumber-1),
+	}
+}
+
+func TestFilePublisherRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocks.log")
+
+	publisher, err := NewFilePublisher(path)
+	assert.NoError(t, err)
+
+	processor := NewRevertableProcessor(publisher, encodeTestData)
+	for i := uint64(1); i <= 3; i++ {
+		processor.Process(context.Background(), poll.Revertable[testutil.Data]{Data: newTestData(i)})
+	}
+	assert.NoError(t, publisher.Close())
+
+	// resume from an earlier checkpoint after restarted, and chain reorg happened
+	publisher, err = NewFilePublisher(path)
+	assert.NoError(t, err)
+
+	processor = NewRevertableProcessor(publisher, encodeTestData)
+	for i := uint64(1); i <= 2; i++ {
+		processor.Process(context.Background(), poll.Revertable[testutil.Data]{Data: newTestData(i)})
+	}
+
+	rewind := poll.Rewind{
+		ForkBlockNumber: 2,
+		ForkBlockHash:   "DataHash-2",
+		Orphaned:        []poll.OrphanedBlock{{Number: 3, Hash: "DataHash-3"}},
+	}
+	forked := testutil.Data{Number: 3, Hash: "DataHash-33", ParentHash: "DataHash-2"}
+	processor.Process(context.Background(), poll.Revertable[testutil.Data]{Data: forked, Reverted: true, Rewind: &rewind})
+
+	// chain reorg back to the orphaned block
+	rewind = poll.Rewind{
+		ForkBlockNumber: 2,
+		ForkBlockHash:   "DataHash-2",
+		Orphaned:        []poll.OrphanedBlock{{Number: 3, Hash: "DataHash-33"}},
+	}
+	processor.Process(context.Background(), poll.Revertable[testutil.Data]{Data: newTestData(3), Reverted: true, Rewind: &rewind})
+	assert.NoError(t, publisher.Close())
+
+	messages, err := ReadFileLog(path)
+	assert.NoError(t, err)
+
+	var keys []string
+	for _, v := range messages {
+		keys = append(keys, v.Key)
+	}
+
+	assert.Equal(t, []string{
+		"DataHash-1", "DataHash-2", "DataHash-3",
+		"rewind:DataHash-2:DataHash-3", "DataHash-33",
+		"rewind:DataHash-2:DataHash-33", "DataHash-3",
+	}, keys)
+}
+
+func TestMemoryPublisherBounded(t *testing.T) {
+	publisher := NewMemoryPublisher()
+
+	for i := uint64(1); i <= 2*publishedKeysWindow; i++ {
+		msg, _ := encodeTestData(newTestData(i))
+		assert.NoError(t, publisher.Publish(context.Background(), msg))
+	}
+
+	assert.Equal(t, publishedKeysWindow, len(publisher.keys.blocks))
+	assert.Equal(t, uint64(publishedKeysWindow+1), publisher.keys.earliest)
+
+	// recent message ignored
+	msg, _ := encodeTestData(newTestData(2 * publishedKeysWindow))
+	assert.NoError(t, publisher.Publish(context.Background(), msg))
+	assert.Equal(t, 2*publishedKeysWindow, len(publisher.Messages()))
+
+	// not in sequence
+	msg, _ = encodeTestData(newTestData(10 * publishedKeysWindow))
+	assert.NoError(t, publisher.Publish(context.Background(), msg))
+	assert.Equal(t, 1, len(publisher.keys.blocks))
+	assert.Equal(t, uint64(9*publishedKeysWindow+1), publisher.keys.earliest)
+}
@@ -0,0 +1,159 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/Conflux-Chain/go-conflux-util/blockchain/sync/core"
+	"github.com/Conflux-Chain/go-conflux-util/blockchain/sync/evm"
+	"github.com/pkg/errors"
+)
+
+var ModuleName = "sync.process.stream"
+
+type MessageType string
+
+const (
+	MessageTypeBlock  MessageType = "block"  // blockchain data of a block (or epoch)
+	MessageTypeRewind MessageType = "rewind" // chain reorg, in which the orphaned blocks should be reverted
+)
+
+// Message is the serialized blockchain data to publish.
+type Message struct {
+	Key         string      `json:"key"` // block hash for block message, so that consumers could deduplicate
+	Type        MessageType `json:"type"`
+	BlockNumber uint64      `json:"blockNumber"` // fork block number for rewind message
+	Value       []byte      `json:"value"`
+}
+
+// Publisher is implemented by types that publish messages to downstream, e.g. Kafka or NATS.
+type Publisher interface {
+	// Publish publishes the given messages in order.
+	//
+	// Note, the given messages may be published again if failed, e.g. partially published, or sync task resumed
+	// from an earlier checkpoint after service restarted. So, implementations should be idempotent by message key.
+	Publish(ctx context.Context, messages ...Message) error
+}
+
+// publishedKeysWindow is the max number of recent blocks to track published keys, which should be much larger than
+// the reorg window. Messages of earlier blocks may be published again, e.g. sync task resumed from a much earlier
+// checkpoint, and consumers should deduplicate by message key in this case.
+const publishedKeysWindow = 10000
+
+// publishedKeys tracks the keys of published messages to deduplicate messages that published again, e.g. partially
+// published before, or sync task resumed from an earlier checkpoint after service restarted.
+type publishedKeys struct {
+	blocks     map[uint64]string // block number => key of block messages
+	earliest   uint64            // the earliest tracked block number
+	lastRewind string            // key of the last rewind message
+}
+
+func newPublishedKeys() *publishedKeys {
+	return &publishedKeys{
+		blocks: make(map[uint64]string),
+	}
+}
+
+// published returns whether the given message has already been published.
+//
+// Note, the same rewind message may be published again if chain reorg back and forth, so it is regarded as
+// published only if it is the same as the last rewind message.
+func (keys *publishedKeys) published(msg Message) bool {
+	if msg.Type == MessageTypeRewind {
+		return msg.Key == keys.lastRewind
+	}
+
+	key, ok := keys.blocks[msg.BlockNumber]
+
+	return ok && key == msg.Key
+}
+
+// add adds the given message as published, and removes the orphaned blocks for rewind message, so that they
+// could be published again.
+func (keys *publishedKeys) add(msg Message) {
+	if msg.Type != MessageTypeRewind {
+		keys.addBlock(msg.BlockNumber, msg.Key)
+		return
+	}
+
+	keys.lastRewind = msg.Key
+
+	for bn := msg.BlockNumber + 1; ; bn++ {
+		if _, ok := keys.blocks[bn]; !ok {
+			break
+		}
+
+		delete(keys.blocks, bn)
+	}
+}
+
+// addBlock adds the key of block message, and evicts blocks out of window.
+func (keys *publishedKeys) addBlock(blockNumber uint64, key string) {
+	if len(keys.blocks) == 0 || blockNumber < keys.earliest {
+		keys.earliest = blockNumber
+	}
+
+	keys.blocks[blockNumber] = key
+
+	if blockNumber < publishedKeysWindow {
+		return
+	}
+
+	evictUntil := blockNumber - publishedKeysWindow + 1
+	if keys.earliest >= evictUntil {
+		return
+	}
+
+	// not in sequence, e.g. resumed from a much later checkpoint
+	if evictUntil-keys.earliest > uint64(len(keys.blocks)) {
+		for bn := range keys.blocks {
+			if bn < evictUntil {
+				delete(keys.blocks, bn)
+			}
+		}
+
+		keys.earliest = evictUntil
+
+		return
+	}
+
+	for ; keys.earliest < evictUntil; keys.earliest++ {
+		delete(keys.blocks, keys.earliest)
+	}
+}
+
+// EncodeFunc serializes the blockchain data into a message.
+type EncodeFunc[T any] func(data T) (Message, error)
+
+// EncodeBlockData serializes eSpace block data into a message in JSON format.
+func EncodeBlockData(data evm.BlockData) (Message, error) {
+	value, err := json.Marshal(data)
+	if err != nil {
+		return Message{}, errors.WithMessage(err, "Failed to marshal block data")
+	}
+
+	return Message{
+		Key:         data.Block.Hash.Hex(),
+		Type:        MessageTypeBlock,
+		BlockNumber: data.Block.Number.Uint64(),
+		Value:       value,
+	}, nil
+}
+
+// EncodeEpochData serializes core space epoch data into a message in JSON format, which is keyed by
+// the pivot block hash.
+func EncodeEpochData(data core.EpochData) (Message, error) {
+	value, err := json.Marshal(data)
+	if err != nil {
+		return Message{}, errors.WithMessage(err, "Failed to marshal epoch data")
+	}
+
+	pivotBlock := data.Blocks[len(data.Blocks)-1]
+
+	return Message{
+		Key:         pivotBlock.Hash.String(),
+		Type:        MessageTypeBlock,
+		BlockNumber: pivotBlock.EpochNumber.ToInt().Uint64(),
+		Value:       value,
+	}, nil
+}
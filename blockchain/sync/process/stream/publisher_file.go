@@ -0,0 +1,137 @@
+package stream
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// FilePublisher publishes messages into an append-only log file, in which each line is a message in JSON format.
+type FilePublisher struct {
+	mu   sync.Mutex
+	file *os.File
+	keys *publishedKeys // keys of messages in log file
+}
+
+// NewFilePublisher opens or creates the log file of the given path to publish messages.
+func NewFilePublisher(path string) (*FilePublisher, error) {
+	messages, size, err := readFileLog(path)
+	if err != nil && !os.IsNotExist(errors.Cause(err)) {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to open log file")
+	}
+
+	// remove the last incomplete line if any
+	if err = file.Truncate(size); err != nil {
+		file.Close()
+		return nil, errors.WithMessage(err, "Failed to truncate log file")
+	}
+
+	publisher := FilePublisher{
+		file: file,
+		keys: newPublishedKeys(),
+	}
+
+	for _, v := range messages {
+		publisher.keys.add(v)
+	}
+
+	return &publisher, nil
+}
+
+// Publish implements the Publisher interface, and ignores messages that already published in log file.
+//
+// Note, messages are flushed to disk before returned.
+func (publisher *FilePublisher) Publish(ctx context.Context, messages ...Message) error {
+	publisher.mu.Lock()
+	defer publisher.mu.Unlock()
+
+	var buf bytes.Buffer
+	var published []Message
+
+	for _, v := range messages {
+		if publisher.keys.published(v) {
+			continue
+		}
+
+		line, err := json.Marshal(v)
+		if err != nil {
+			return errors.WithMessage(err, "Failed to marshal message")
+		}
+
+		buf.Write(line)
+		buf.WriteByte('\n')
+		published = append(published, v)
+	}
+
+	if buf.Len() == 0 {
+		return nil
+	}
+
+	if _, err := publisher.file.Write(buf.Bytes()); err != nil {
+		return errors.WithMessage(err, "Failed to write log file")
+	}
+
+	if err := publisher.file.Sync(); err != nil {
+		return errors.WithMessage(err, "Failed to sync log file")
+	}
+
+	for _, v := range published {
+		publisher.keys.add(v)
+	}
+
+	return nil
+}
+
+// Close closes the underlying log file.
+func (publisher *FilePublisher) Close() error {
+	return publisher.file.Close()
+}
+
+// ReadFileLog reads all messages in order from the log file of the given path.
+//
+// Note, the last incomplete line, e.g. partially written, will be ignored.
+func ReadFileLog(path string) ([]Message, error) {
+	messages, _, err := readFileLog(path)
+	return messages, err
+}
+
+// readFileLog reads all messages from log file, and returns the size of all complete lines.
+func readFileLog(path string) (messages []Message, size int64, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, 0, errors.WithMessage(err, "Failed to open log file")
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			return messages, size, nil
+		}
+
+		if err != nil {
+			return nil, 0, errors.WithMessage(err, "Failed to read log file")
+		}
+
+		var msg Message
+		if err = json.Unmarshal(line, &msg); err != nil {
+			return nil, 0, errors.WithMessagef(err, "Failed to unmarshal message at line %v", len(messages)+1)
+		}
+
+		messages = append(messages, msg)
+		size += int64(len(line))
+	}
+}
@@ -0,0 +1,45 @@
+package stream
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// MemoryPublisher publishes messages in memory, which is mainly used for testing.
+type MemoryPublisher struct {
+	mu       sync.Mutex
+	messages []Message
+	keys     *publishedKeys
+}
+
+func NewMemoryPublisher() *MemoryPublisher {
+	return &MemoryPublisher{
+		keys: newPublishedKeys(),
+	}
+}
+
+// Publish implements the Publisher interface, and ignores messages that already published.
+func (publisher *MemoryPublisher) Publish(ctx context.Context, messages ...Message) error {
+	publisher.mu.Lock()
+	defer publisher.mu.Unlock()
+
+	for _, v := range messages {
+		if publisher.keys.published(v) {
+			continue
+		}
+
+		publisher.messages = append(publisher.messages, v)
+		publisher.keys.add(v)
+	}
+
+	return nil
+}
+
+// Messages returns all published messages in order.
+func (publisher *MemoryPublisher) Messages() []Message {
+	publisher.mu.Lock()
+	defer publisher.mu.Unlock()
+
+	return slices.Clone(publisher.messages)
+}
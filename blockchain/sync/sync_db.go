@@ -2,6 +2,7 @@ package sync
 
 import (
 	"context"
+	"slices"
 	"sync"
 
 	"github.com/Conflux-Chain/go-conflux-util/blockchain/sync/poll"
@@ -12,12 +13,17 @@ import (
 	"gorm.io/gorm"
 )
 
+var ModuleName = "sync"
+
 type CatchupParamsDB[T any] struct {
 	Adapter         poll.Adapter[T]
 	Poller          poll.CatchUpOption
 	Processor       db.BatchOption
 	DB              *gorm.DB
 	NextBlockNumber uint64
+
+	// optional, if specified, sync from the checkpoint instead of NextBlockNumber
+	Checkpoint *CheckpointProcessor[T]
 }
 
 type ParamsDB[T any] struct {
@@ -29,14 +35,30 @@ type ParamsDB[T any] struct {
 
 	// only used to sync latest data, and usually loads from database
 	Reorg poll.ReorgWindowParams
+
+	// optional, if specified, sync from the checkpoint instead of NextBlockNumber and Reorg
+	Checkpoint *CheckpointProcessor[T]
 }
 
 func CatchUpDB[T channel.Sizable](ctx context.Context, params CatchupParamsDB[T], processors ...db.BatchProcessor[T]) uint64 {
+	return catchUpDB(ctx, ctx, params, processors...)
+}
+
+// catchUpDB terminates the poller once pollCtx done, and then the processor will terminate after all polled data
+// processed, including the last batch.
+func catchUpDB[T channel.Sizable](
+	ctx, pollCtx context.Context, params CatchupParamsDB[T], processors ...db.BatchProcessor[T],
+) uint64 {
+	if params.Checkpoint != nil {
+		params.NextBlockNumber = params.Checkpoint.NextBlockNumber()
+		processors = append(slices.Clone(processors), params.Checkpoint)
+	}
+
 	var wg sync.WaitGroup
 
 	poller := poll.NewCatchUpPoller(params.Adapter, params.NextBlockNumber, params.Poller)
 	wg.Add(1)
-	go poller.Poll(ctx, &wg)
+	go poller.Poll(pollCtx, &wg)
 
 	processor := db.NewBatchAggregateProcessor(params.Processor, params.DB, processors...)
 	wg.Add(1)
@@ -48,6 +70,11 @@ func CatchUpDB[T channel.Sizable](ctx context.Context, params CatchupParamsDB[T]
 }
 
 func StartFinalizedDB[T any](ctx context.Context, wg *sync.WaitGroup, params ParamsDB[T], processors ...db.Processor[T]) {
+	if params.Checkpoint != nil {
+		params.NextBlockNumber = params.Checkpoint.NextBlockNumber()
+		processors = append(slices.Clone(processors), params.Checkpoint)
+	}
+
 	poller := poll.NewFinalizedPoller(params.Adapter, params.NextBlockNumber, params.Poller)
 	wg.Add(1)
 	go poller.Poll(ctx, wg)
@@ -58,6 +85,12 @@ func StartFinalizedDB[T any](ctx context.Context, wg *sync.WaitGroup, params Par
 }
 
 func StartLatestDB[T any](ctx context.Context, wg *sync.WaitGroup, params ParamsDB[T], processors ...db.RevertableProcessor[T]) error {
+	if params.Checkpoint != nil {
+		params.NextBlockNumber = params.Checkpoint.NextBlockNumber()
+		params.Reorg = params.Checkpoint.ReorgWindowParams()
+		processors = append(slices.Clone(processors), params.Checkpoint)
+	}
+
 	poller, err := poll.NewLatestPoller(params.Adapter, params.NextBlockNumber, params.Reorg, params.Poller)
 	if err != nil {
 		return errors.WithMessage(err, "Failed to create latest poller")
@@ -3,6 +3,7 @@ package sync
 import (
 	"encoding/json"
 	"slices"
+	"sync"
 	"testing"
 	"time"
 
@@ -17,6 +18,8 @@ type dummyDBOp struct{}
 func (op dummyDBOp) Exec(tx *gorm.DB) error { return nil }
 
 type testDBProcessor struct {
+	mu sync.Mutex // guards data accessed by waitFor concurrently
+
 	batch   []testutil.Data
 	batches [][]testutil.Data
 
@@ -46,6 +49,9 @@ func (p *testDBProcessor) requiresContinuous(data testutil.Data) {
 }
 
 func (p *testDBProcessor) Process(data testutil.Data) db.Operation {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
 	p.requiresContinuous(data)
 
 	p.singles = append(p.singles, data)
@@ -56,6 +62,9 @@ func (p *testDBProcessor) Process(data testutil.Data) db.Operation {
 }
 
 func (p *testDBProcessor) Revert(data testutil.Data) db.Operation {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
 	if len(p.singles) == 0 {
 		panic("No block to revert")
 	}
@@ -86,6 +95,9 @@ func (p *testDBProcessor) Revert(data testutil.Data) db.Operation {
 }
 
 func (p *testDBProcessor) BatchProcess(data testutil.Data) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
 	p.requiresContinuous(data)
 
 	p.batch = append(p.batch, data)
@@ -100,6 +112,9 @@ func (p *testDBProcessor) BatchExec(tx *gorm.DB, createBatchSize int) error {
 }
 
 func (p *testDBProcessor) BatchReset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
 	p.batches = append(p.batches, p.batch)
 	p.batch = nil
 }
@@ -143,7 +158,11 @@ func (p *testDBProcessor) waitFor(t *testing.T, data testutil.Data) {
 	for i := 0; i < 30; i++ {
 		time.Sleep(100 * time.Millisecond)
 
-		if p.prev == data {
+		p.mu.Lock()
+		prev := p.prev
+		p.mu.Unlock()
+
+		if prev == data {
 			return
 		}
 	}
@@ -0,0 +1,66 @@
+package sync
+
+import (
+	"context"
+	"math"
+	"sync"
+
+	"github.com/Conflux-Chain/go-conflux-util/blockchain/sync/poll"
+	"github.com/Conflux-Chain/go-conflux-util/blockchain/sync/process/export"
+	"github.com/Conflux-Chain/go-conflux-util/channel"
+)
+
+// CatchUpExport exports blockchain data into files till the latest finalized block, and resumes from
+// the manifest of the given export processor.
+//
+// It returns the next block number to export, along with any error occurred to export.
+func CatchUpExport[T channel.Sizable](ctx context.Context, adapter poll.Adapter[T], option poll.CatchUpOption, processor *export.Processor[T]) (uint64, error) {
+	return ExportRange(ctx, adapter, math.MaxUint64, option, processor)
+}
+
+// ExportRange is similar to CatchUpExport, but exports blockchain data till the given end block number (inclusive)
+// or the latest finalized block, whichever is smaller.
+func ExportRange[T channel.Sizable](
+	ctx context.Context, adapter poll.Adapter[T], endBlockNumber uint64, option poll.CatchUpOption, processor *export.Processor[T],
+) (uint64, error) {
+	// terminate poller once failed to export
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+
+	poller := poll.NewBoundedCatchUpPoller(adapter, processor.NextBlockNumber(), endBlockNumber, option)
+	wg.Add(1)
+	go poller.Poll(ctx, &wg)
+
+	err := exportData(ctx, poller.DataCh(), processor)
+
+	cancel()
+	wg.Wait()
+
+	return processor.NextBlockNumber(), err
+}
+
+// exportData exports the polled blockchain data till channel closed or context done, and commits the incomplete
+// partition once all data exported.
+func exportData[T any](ctx context.Context, dataCh <-chan T, processor *export.Processor[T]) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case data, ok := <-dataCh:
+			if !ok {
+				// channel closed due to context done
+				if ctx.Err() != nil {
+					return nil
+				}
+
+				return processor.Flush()
+			}
+
+			if err := processor.Export(data); err != nil {
+				return err
+			}
+		}
+	}
+}
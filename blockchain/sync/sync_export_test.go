@@ -0,0 +1,63 @@
+package sync
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Conflux-Chain/go-conflux-util/blockchain/sync/poll"
+	"github.com/Conflux-Chain/go-conflux-util/blockchain/sync/poll/testutil"
+	"github.com/Conflux-Chain/go-conflux-util/blockchain/sync/process/export"
+	"github.com/stretchr/testify/assert"
+)
+
+func extractSyncTestData(data testutil.Data) export.Rows {
+	return export.Rows{
+		BlockNumber: data.Number,
+		BlockHash:   data.Hash,
+		Tables:      map[string][]any{export.TableBlocks: {data}},
+	}
+}
+
+func TestCatchUpExport(t *testing.T) {
+	adapter := testutil.MustNewAdapter([]uint64{3, 5}, nil)
+
+	processor, err := export.NewProcessor(extractSyncTestData, 2, export.Option{Dir: t.TempDir(), PartitionSize: 4})
+	assert.NoError(t, err)
+
+	nextBlockNumber, err := CatchUpExport(context.Background(), adapter, poll.CatchUpOption{}, processor)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(6), nextBlockNumber)
+	assert.Equal(t, uint64(6), processor.NextBlockNumber())
+
+	manifest := processor.Manifest()
+	assert.Equal(t, 2, len(manifest.Partitions))
+	assert.Equal(t, uint64(3), manifest.Partitions[0].To)
+	assert.Equal(t, uint64(5), manifest.Partitions[1].To)
+}
+
+func TestExportRange(t *testing.T) {
+	adapter := testutil.MustNewAdapter([]uint64{3, 5}, nil)
+
+	processor, err := export.NewProcessor(extractSyncTestData, 2, export.Option{Dir: t.TempDir(), PartitionSize: 4})
+	assert.NoError(t, err)
+
+	// export till the end block
+	nextBlockNumber, err := ExportRange(context.Background(), adapter, 4, poll.CatchUpOption{}, processor)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(5), nextBlockNumber)
+
+	manifest := processor.Manifest()
+	assert.Equal(t, 2, len(manifest.Partitions))
+	assert.Equal(t, uint64(3), manifest.Partitions[0].To)
+	assert.Equal(t, uint64(4), manifest.Partitions[1].To)
+
+	// failed to export
+	processor, err = export.NewProcessor(func(data testutil.Data) export.Rows {
+		return export.Rows{BlockNumber: data.Number + 1}
+	}, 2, export.Option{Dir: t.TempDir()})
+	assert.NoError(t, err)
+
+	nextBlockNumber, err = ExportRange(context.Background(), adapter, 4, poll.CatchUpOption{}, processor)
+	assert.Error(t, err)
+	assert.Equal(t, uint64(2), nextBlockNumber)
+}
@@ -0,0 +1,380 @@
+package sync
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Conflux-Chain/go-conflux-util/blockchain/sync/poll"
+	"github.com/Conflux-Chain/go-conflux-util/blockchain/sync/process"
+	"github.com/Conflux-Chain/go-conflux-util/blockchain/sync/process/db"
+	"github.com/Conflux-Chain/go-conflux-util/channel"
+	"github.com/Conflux-Chain/go-conflux-util/log"
+	"github.com/Conflux-Chain/go-conflux-util/metrics"
+	"github.com/mcuadros/go-defaults"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// Phase represents the sync phase of Syncer.
+type Phase int32
+
+const (
+	PhaseIdle Phase = iota
+	PhaseCatchUp
+	PhaseFinalized
+	PhaseLatest
+	PhasePaused
+)
+
+func (phase Phase) String() string {
+	switch phase {
+	case PhaseIdle:
+		return "idle"
+	case PhaseCatchUp:
+		return "catchup"
+	case PhaseFinalized:
+		return "finalized"
+	case PhaseLatest:
+		return "latest"
+	case PhasePaused:
+		return "paused"
+	default:
+		return "unknown"
+	}
+}
+
+type SyncerOption struct {
+	CatchUp    poll.CatchUpOption
+	Poller     poll.Option
+	Processor  db.BatchOption
+	Checkpoint CheckpointOption
+
+	// Latest indicates whether to sync the latest data and handle chain reorg after caught up,
+	// otherwise, only sync the finalized data.
+	Latest bool
+
+	// CatchUpThreshold is the number of finalized blocks fell behind to switch back to catch up phase.
+	CatchUpThreshold uint64 `default:"1000"`
+
+	// CatchUpCheckInterval is the interval to check whether fell behind too much.
+	CatchUpCheckInterval time.Duration `default:"1m"`
+
+	// ReorgErrorRetry is the max number of consecutive retries when failed to push block into reorg window in
+	// latest phase, e.g. fullnode reports an inconsistent finalized block temporarily. Then, the reorg window will
+	// be rebuilt from checkpoint.
+	ReorgErrorRetry int `default:"3"`
+
+	// StallTimeout is the max duration without any block processed to report healthy in Status.
+	StallTimeout time.Duration `default:"5m"`
+
+	// ReindexBatchSize is the number of blocks to re-index in a database transaction.
+	ReindexBatchSize uint64 `default:"100"`
+
+	// Metrics is used to namespace metrics of pollers and processors if not specified, so that multiple
+	// syncers could run in one process, e.g. "espace" and "core".
+	Metrics poll.MetricsOption
+}
+
+type SyncerParams[T any] struct {
+	Adapter         poll.Adapter[T]
+	DB              *gorm.DB
+	NextBlockNumber uint64
+
+	// optional, if specified, sync from the checkpoint instead of NextBlockNumber
+	Checkpoint Checkpoint
+
+	// processors used in catch up phase, each of which must be one of Processors as well, so that data
+	// written in batch could be reverted, e.g. by Rewind or Reindex.
+	BatchProcessors []db.BatchProcessor[T]
+
+	// processors used in finalized or latest phase
+	Processors []db.RevertableProcessor[T]
+}
+
+// Syncer synchronizes blockchain data into database in the whole lifecycle.
+//
+// At first, it catches up to the finalized block in batch. Then, it switches to synchronize the finalized
+// or latest data block by block. If fell behind too much, e.g. RPC outage for a long time, it will switch
+// back to catch up phase again.
+type Syncer[T channel.Sizable] struct {
+	option          SyncerOption
+	adapter         poll.Adapter[T]
+	db              *gorm.DB
+	checkpoint      *CheckpointProcessor[T]
+	batchProcessors []db.BatchProcessor[T]
+	processors      []db.RevertableProcessor[T]
+	phase           atomic.Int32
+	pauser          pauser
+	controlMu       sync.Mutex // to execute control commands in sequence, e.g. pause and rewind
+}
+
+func NewSyncer[T channel.Sizable](params SyncerParams[T], option ...SyncerOption) (*Syncer[T], error) {
+	var opt SyncerOption
+	if len(option) > 0 {
+		opt = option[0]
+	}
+
+	defaults.SetDefaults(&opt)
+
+	for _, v := range params.BatchProcessors {
+		if !containsProcessor(params.Processors, v) {
+			return nil, errors.Errorf("Batch processor %T not found in processors", v)
+		}
+	}
+
+	if opt.CatchUp.Parallel.Metrics == (poll.MetricsOption{}) {
+		opt.CatchUp.Parallel.Metrics = opt.Metrics
+	}
+
+	if opt.Poller.Metrics == (poll.MetricsOption{}) {
+		opt.Poller.Metrics = opt.Metrics
+	}
+
+	if opt.Processor.Metrics == (poll.MetricsOption{}) {
+		opt.Processor.Metrics = opt.Metrics
+	}
+
+	checkpoint, err := NewCheckpointProcessor(params.Checkpoint, params.Adapter, params.NextBlockNumber, opt.Checkpoint)
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to create checkpoint processor")
+	}
+
+	return &Syncer[T]{
+		option:          opt,
+		adapter:         params.Adapter,
+		db:              params.DB,
+		checkpoint:      checkpoint,
+		batchProcessors: params.BatchProcessors,
+		processors:      params.Processors,
+	}, nil
+}
+
+// containsProcessor returns whether the given batch processor is one of the revertable processors.
+func containsProcessor[T any](processors []db.RevertableProcessor[T], processor db.BatchProcessor[T]) bool {
+	// avoid to panic when comparing values of uncomparable type
+	if processor == nil || !reflect.TypeOf(processor).Comparable() {
+		return false
+	}
+
+	for _, v := range processors {
+		if any(v) == any(processor) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Phase returns the current sync phase.
+func (syncer *Syncer[T]) Phase() Phase {
+	return Phase(syncer.phase.Load())
+}
+
+func (syncer *Syncer[T]) setPhase(phase Phase) {
+	metrics.GetOrRegisterGauge("%v", syncer.option.Metrics.Name("phase")).Update(int64(phase))
+
+	if old := Phase(syncer.phase.Swap(int32(phase))); old != phase {
+		log.WithModule(ModuleName).WithFields(logrus.Fields{
+			"from": old,
+			"to":   phase,
+			"next": syncer.checkpoint.NextBlockNumber(),
+		}).Info("Sync phase changed")
+	}
+}
+
+// Sync synchronizes blockchain data until the given context done.
+func (syncer *Syncer[T]) Sync(ctx context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+	defer syncer.setPhase(PhaseIdle)
+
+	for {
+		// blocks if paused
+		runCtx, ok := syncer.pauser.begin(ctx)
+		if !ok {
+			return
+		}
+
+		syncer.run(ctx, runCtx)
+
+		if ctx.Err() == nil {
+			syncer.setPhase(PhasePaused)
+		}
+
+		syncer.pauser.end()
+
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// run synchronizes blockchain data until runCtx done, e.g. paused. Note, pollers terminate once runCtx done,
+// and processors terminate after all polled data processed, unless ctx done.
+func (syncer *Syncer[T]) run(ctx, runCtx context.Context) {
+	for {
+		syncer.setPhase(PhaseCatchUp)
+
+		catchUpDB(ctx, runCtx, CatchupParamsDB[T]{
+			Adapter:    syncer.adapter,
+			Poller:     syncer.option.CatchUp,
+			Processor:  syncer.option.Processor,
+			DB:         syncer.db,
+			Checkpoint: syncer.checkpoint,
+		}, syncer.batchProcessors...)
+
+		if err := runCtx.Err(); err != nil {
+			return
+		}
+
+		if fellBehind := syncer.follow(ctx, runCtx); !fellBehind {
+			return
+		}
+	}
+}
+
+// follow synchronizes the finalized or latest data block by block, and returns true if fell behind too much.
+func (syncer *Syncer[T]) follow(ctx, runCtx context.Context) bool {
+	for {
+		fellBehind, err := syncer.followOnce(ctx, runCtx)
+		if err != nil {
+			log.WithModule(ModuleName).WithError(err).Fatal("Failed to sync data block by block")
+		}
+
+		if runCtx.Err() != nil {
+			return false
+		}
+
+		if fellBehind && syncer.verifyLastBlock(runCtx) {
+			return true
+		}
+	}
+}
+
+func (syncer *Syncer[T]) followOnce(ctx, runCtx context.Context) (fellBehind bool, err error) {
+	var wg sync.WaitGroup
+
+	// Terminate poller at first, and then the processor will terminate once all polled data processed.
+	pollCtx, cancel := context.WithCancel(runCtx)
+	defer wg.Wait()
+	defer cancel()
+
+	var nextBlockNumber func() uint64
+	var pollDone chan struct{} // closed once the latest poller terminated
+
+	if syncer.option.Latest {
+		syncer.setPhase(PhaseLatest)
+
+		poller, err := poll.NewLatestPoller(syncer.adapter, syncer.checkpoint.NextBlockNumber(), syncer.checkpoint.ReorgWindowParams(), syncer.option.Poller)
+		if err != nil {
+			return false, errors.WithMessage(err, "Failed to create latest poller")
+		}
+
+		poller.SetReorgErrorHandler(func(err *poll.ReorgError) bool {
+			return err.Consecutive <= syncer.option.ReorgErrorRetry
+		})
+
+		processors := append(slices.Clone(syncer.processors), syncer.checkpoint)
+		processor := db.NewRevertableAggregateProcessor(syncer.option.Processor.Option, syncer.db, processors...)
+
+		pollDone = make(chan struct{})
+
+		wg.Add(2)
+		go func() {
+			defer close(pollDone)
+			poller.Poll(pollCtx, &wg)
+		}()
+		go process.Process(ctx, &wg, poller.DataCh(), processor)
+
+		nextBlockNumber = poller.NextBlockNumber
+	} else {
+		syncer.setPhase(PhaseFinalized)
+
+		poller := poll.NewFinalizedPoller(syncer.adapter, syncer.checkpoint.NextBlockNumber(), syncer.option.Poller)
+
+		processors := make([]db.Processor[T], 0, len(syncer.processors)+1)
+		for _, v := range syncer.processors {
+			processors = append(processors, v)
+		}
+		processors = append(processors, syncer.checkpoint)
+		processor := db.NewAggregateProcessor(syncer.option.Processor.Option, syncer.db, processors...)
+
+		wg.Add(2)
+		go poller.Poll(pollCtx, &wg)
+		go process.Process(ctx, &wg, poller.DataCh(), processor)
+
+		nextBlockNumber = poller.NextBlockNumber
+	}
+
+	ticker := time.NewTicker(syncer.option.CatchUpCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-runCtx.Done():
+			return false, nil
+		case <-pollDone:
+			log.WithModule(ModuleName).WithField("retry", syncer.option.ReorgErrorRetry).Warn(
+				"Too many reorg errors, rebuild reorg window from checkpoint",
+			)
+			return false, nil
+		case <-ticker.C:
+			if syncer.fellBehind(runCtx, nextBlockNumber()) {
+				return true, nil
+			}
+		}
+	}
+}
+
+func (syncer *Syncer[T]) fellBehind(ctx context.Context, nextBlockNumber uint64) bool {
+	finalizedBlockNumber, err := syncer.adapter.GetFinalizedBlockNumber(ctx)
+	if err != nil {
+		log.WithModule(ModuleName).WithError(err).Debug("Failed to get finalized block number to check sync lag")
+		return false
+	}
+
+	if finalizedBlockNumber < nextBlockNumber+syncer.option.CatchUpThreshold {
+		return false
+	}
+
+	log.WithModule(ModuleName).WithFields(logrus.Fields{
+		"next":      nextBlockNumber,
+		"finalized": finalizedBlockNumber,
+	}).Info("Fell behind too much, switch to catch up phase")
+
+	return true
+}
+
+// verifyLastBlock checks whether the last processed block is still on chain. Otherwise, chain reorg
+// should be handled in latest phase before switching to catch up phase.
+func (syncer *Syncer[T]) verifyLastBlock(ctx context.Context) bool {
+	if !syncer.option.Latest {
+		return true
+	}
+
+	last, ok := syncer.checkpoint.LastBlock()
+	if !ok {
+		return true
+	}
+
+	data, err := syncer.adapter.GetBlockData(ctx, last.Number)
+	if err != nil {
+		log.WithModule(ModuleName).WithError(err).WithField("block", last.Number).Debug("Failed to get block data to verify")
+		return false
+	}
+
+	if hash := syncer.adapter.GetBlockHash(data); hash != last.Hash {
+		log.WithModule(ModuleName).WithFields(logrus.Fields{
+			"block":    last.Number,
+			"expected": last.Hash,
+			"actual":   hash,
+		}).Info("Last processed block reverted, continue to sync the latest data")
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,335 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Conflux-Chain/go-conflux-util/blockchain/sync/process/db"
+	"github.com/Conflux-Chain/go-conflux-util/log"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrBlockNotProcessed is returned when the block to rewind or re-index has not been processed yet.
+	ErrBlockNotProcessed = errors.New("Block not processed yet")
+
+	// ErrBlockNotFinalized is returned when the block to re-index has not been finalized yet.
+	ErrBlockNotFinalized = errors.New("Block not finalized yet")
+)
+
+// pauser allows to pause and resume the sync pipeline at runtime.
+type pauser struct {
+	mu        sync.Mutex
+	paused    bool
+	resumed   chan struct{}      // closed once resumed
+	cancel    context.CancelFunc // cancels the running pipeline, nil if not running
+	stopped   chan struct{}      // closed once the running pipeline terminated
+	startedAt time.Time          // time when the running pipeline started
+}
+
+// begin blocks until not paused, and returns a context for the sync pipeline to run, which will be cancelled
+// once paused. Returns false if the given context done.
+func (p *pauser) begin(ctx context.Context) (context.Context, bool) {
+	for {
+		p.mu.Lock()
+
+		if !p.paused {
+			runCtx, cancel := context.WithCancel(ctx)
+			p.cancel = cancel
+			p.stopped = make(chan struct{})
+			p.startedAt = time.Now()
+			p.mu.Unlock()
+
+			return runCtx, true
+		}
+
+		resumed := p.resumed
+		p.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, false
+		case <-resumed:
+		}
+	}
+}
+
+// end should be called once the sync pipeline terminated.
+func (p *pauser) end() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.cancel()
+	p.cancel = nil
+	close(p.stopped)
+}
+
+// pause cancels the running pipeline if any, and waits for termination. Returns false if already paused.
+func (p *pauser) pause(ctx context.Context) (bool, error) {
+	p.mu.Lock()
+
+	if p.paused {
+		p.mu.Unlock()
+		return false, nil
+	}
+
+	p.paused = true
+	p.resumed = make(chan struct{})
+
+	var stopped chan struct{}
+	if p.cancel != nil {
+		p.cancel()
+		stopped = p.stopped
+	}
+
+	p.mu.Unlock()
+
+	if stopped == nil {
+		return true, nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return true, ctx.Err()
+	case <-stopped:
+		return true, nil
+	}
+}
+
+// resume resumes the paused pipeline, and returns false if not paused.
+func (p *pauser) resume() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.paused {
+		return false
+	}
+
+	p.paused = false
+	close(p.resumed)
+
+	return true
+}
+
+func (p *pauser) status() (paused bool, startedAt time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.paused, p.startedAt
+}
+
+// Status is the runtime status of Syncer.
+type Status struct {
+	Phase           string    `json:"phase"`
+	Paused          bool      `json:"paused"`
+	NextBlockNumber uint64    `json:"nextBlockNumber"`
+	ProcessedAt     time.Time `json:"processedAt"` // zero if nothing processed since service started
+
+	// Healthy indicates whether any block processed within SyncerOption.StallTimeout, and it is always
+	// healthy if paused.
+	Healthy bool `json:"healthy"`
+}
+
+// Status returns the runtime status, which is thread-safe.
+func (syncer *Syncer[T]) Status() Status {
+	paused, startedAt := syncer.pauser.status()
+	processedAt := syncer.checkpoint.ProcessedAt()
+	phase := syncer.Phase()
+
+	// no block processed yet since the pipeline started
+	lastActiveAt := processedAt
+	if startedAt.After(lastActiveAt) {
+		lastActiveAt = startedAt
+	}
+
+	healthy := paused || (phase != PhaseIdle && time.Since(lastActiveAt) < syncer.option.StallTimeout)
+
+	return Status{
+		Phase:           phase.String(),
+		Paused:          paused,
+		NextBlockNumber: syncer.checkpoint.NextBlockNumber(),
+		ProcessedAt:     processedAt,
+		Healthy:         healthy,
+	}
+}
+
+// ReorgWindow returns the recently processed blocks in ascending order, which are used to initialize the reorg
+// window when sync the latest data. It is thread-safe.
+func (syncer *Syncer[T]) ReorgWindow() []CheckpointBlock {
+	return syncer.checkpoint.Blocks()
+}
+
+// Pause pauses the sync pipeline, and returns once all polled data processed.
+func (syncer *Syncer[T]) Pause(ctx context.Context) error {
+	syncer.controlMu.Lock()
+	defer syncer.controlMu.Unlock()
+
+	if paused, err := syncer.pauser.pause(ctx); err != nil {
+		return errors.WithMessage(err, "Failed to wait for the sync pipeline terminated")
+	} else if paused {
+		log.WithModule(ModuleName).WithField("next", syncer.checkpoint.NextBlockNumber()).Info("Sync paused")
+	}
+
+	return nil
+}
+
+// Resume resumes the paused sync pipeline.
+func (syncer *Syncer[T]) Resume() {
+	syncer.controlMu.Lock()
+	defer syncer.controlMu.Unlock()
+
+	if syncer.pauser.resume() {
+		log.WithModule(ModuleName).WithField("next", syncer.checkpoint.NextBlockNumber()).Info("Sync resumed")
+	}
+}
+
+// Rewind reverts all the processed blocks since the given block number (inclusive), and then syncs again
+// from the given block number.
+//
+// Note, the sync pipeline is paused during rewinding, and resumed after rewound unless paused already.
+func (syncer *Syncer[T]) Rewind(ctx context.Context, blockNumber uint64) error {
+	syncer.controlMu.Lock()
+	defer syncer.controlMu.Unlock()
+
+	paused, err := syncer.pauser.pause(ctx)
+	if paused {
+		defer syncer.pauser.resume()
+	}
+
+	if err != nil {
+		return errors.WithMessage(err, "Failed to wait for the sync pipeline terminated")
+	}
+
+	nextBlockNumber := syncer.checkpoint.NextBlockNumber()
+	if blockNumber > nextBlockNumber {
+		return fmt.Errorf("%w, next = %v", ErrBlockNotProcessed, nextBlockNumber)
+	}
+
+	if blockNumber == nextBlockNumber {
+		return nil
+	}
+
+	data, err := syncer.adapter.GetBlockData(ctx, blockNumber)
+	if err != nil {
+		return errors.WithMessage(err, "Failed to retrieve blockchain data")
+	}
+
+	// revert in reversed order
+	var ops []db.Operation
+	for _, v := range slices.Backward(syncer.processors) {
+		ops = append(ops, v.Revert(data))
+	}
+
+	op, commit := syncer.checkpoint.rewindTo(blockNumber, syncer.adapter.GetParentBlockHash(data))
+	ops = append(ops, op)
+
+	if err = syncer.db.Transaction(func(tx *gorm.DB) error {
+		return db.ComposeOperation(ops...).Exec(tx)
+	}); err != nil {
+		return errors.WithMessage(err, "Failed to revert data in database")
+	}
+
+	commit()
+
+	log.WithModule(ModuleName).WithFields(logrus.Fields{
+		"from": nextBlockNumber,
+		"to":   blockNumber,
+	}).Info("Sync rewound")
+
+	return nil
+}
+
+// Reindex re-indexes the processed blocks in range [from, to] without reverting the subsequent blocks.
+//
+// Note, all processors must implement the db.RangeRevertableProcessor interface, and only finalized blocks are
+// allowed to re-index. Besides, the sync pipeline is paused during re-indexing, and resumed after re-indexed
+// unless paused already.
+func (syncer *Syncer[T]) Reindex(ctx context.Context, from, to uint64) error {
+	if from > to {
+		return errors.Errorf("Invalid block range, from = %v, to = %v", from, to)
+	}
+
+	processors := make([]db.RangeRevertableProcessor, 0, len(syncer.processors))
+	for _, v := range syncer.processors {
+		processor, ok := v.(db.RangeRevertableProcessor)
+		if !ok {
+			return errors.Errorf("Processor %T does not support to revert data in range", v)
+		}
+
+		processors = append(processors, processor)
+	}
+
+	syncer.controlMu.Lock()
+	defer syncer.controlMu.Unlock()
+
+	finalizedBlockNumber, err := syncer.adapter.GetFinalizedBlockNumber(ctx)
+	if err != nil {
+		return errors.WithMessage(err, "Failed to get finalized block number")
+	}
+
+	if to > finalizedBlockNumber {
+		return fmt.Errorf("%w, finalized = %v", ErrBlockNotFinalized, finalizedBlockNumber)
+	}
+
+	paused, err := syncer.pauser.pause(ctx)
+	if paused {
+		defer syncer.pauser.resume()
+	}
+
+	if err != nil {
+		return errors.WithMessage(err, "Failed to wait for the sync pipeline terminated")
+	}
+
+	if nextBlockNumber := syncer.checkpoint.NextBlockNumber(); to >= nextBlockNumber {
+		return fmt.Errorf("%w, next = %v", ErrBlockNotProcessed, nextBlockNumber)
+	}
+
+	for start := from; start <= to; start += syncer.option.ReindexBatchSize {
+		end := min(start+syncer.option.ReindexBatchSize-1, to)
+
+		if err = syncer.reindex(ctx, processors, start, end); err != nil {
+			return errors.WithMessagef(err, "Failed to re-index blocks in range [%v, %v]", start, end)
+		}
+
+		log.WithModule(ModuleName).WithFields(logrus.Fields{
+			"from": start,
+			"to":   end,
+		}).Debug("Succeeded to re-index blocks")
+	}
+
+	log.WithModule(ModuleName).WithFields(logrus.Fields{
+		"from": from,
+		"to":   to,
+	}).Info("Sync re-indexed")
+
+	return nil
+}
+
+// reindex deletes and processes again the blocks in range [from, to] in a database transaction.
+func (syncer *Syncer[T]) reindex(ctx context.Context, processors []db.RangeRevertableProcessor, from, to uint64) error {
+	var ops []db.Operation
+
+	for _, v := range slices.Backward(processors) {
+		ops = append(ops, v.RevertRange(from, to))
+	}
+
+	for blockNumber := from; blockNumber <= to; blockNumber++ {
+		data, err := syncer.adapter.GetBlockData(ctx, blockNumber)
+		if err != nil {
+			return errors.WithMessagef(err, "Failed to retrieve blockchain data of block %v", blockNumber)
+		}
+
+		for _, v := range syncer.processors {
+			ops = append(ops, v.Process(data))
+		}
+	}
+
+	return syncer.db.Transaction(func(tx *gorm.DB) error {
+		return db.ComposeOperation(ops...).Exec(tx)
+	})
+}
@@ -0,0 +1,185 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Conflux-Chain/go-conflux-util/blockchain/sync/poll"
+	"github.com/Conflux-Chain/go-conflux-util/blockchain/sync/poll/testutil"
+	"github.com/Conflux-Chain/go-conflux-util/blockchain/sync/process/db"
+	"github.com/Conflux-Chain/go-conflux-util/store"
+	"github.com/stretchr/testify/assert"
+)
+
+// syncerTestAdapter is a thread-safe adapter with continuous blocks, and allows to change the
+// finalized and latest block numbers on the fly.
+type syncerTestAdapter struct {
+	finalized atomic.Uint64
+	latest    atomic.Uint64
+	delay     time.Duration
+}
+
+func (adapter *syncerTestAdapter) GetFinalizedBlockNumber(ctx context.Context) (uint64, error) {
+	return adapter.finalized.Load(), nil
+}
+
+func (adapter *syncerTestAdapter) GetLatestBlockNumber(ctx context.Context) (uint64, error) {
+	return adapter.latest.Load(), nil
+}
+
+func (adapter *syncerTestAdapter) GetBlockData(ctx context.Context, blockNumber uint64) (testutil.Data, error) {
+	time.Sleep(adapter.delay)
+
+	data := testutil.Data{
+		Number: blockNumber,
+		Hash:   fmt.Sprintf("DataHash-%v", blockNumber),
+	}
+
+	if blockNumber > 0 {
+		data.ParentHash = fmt.Sprintf("DataHash-%v", blockNumber-1)
+	}
+
+	return data, nil
+}
+
+func (adapter *syncerTestAdapter) GetBlockHash(data testutil.Data) string {
+	return data.Hash
+}
+
+func (adapter *syncerTestAdapter) GetParentBlockHash(data testutil.Data) string {
+	return data.ParentHash
+}
+
+func TestSyncer(t *testing.T) {
+	adapter := &syncerTestAdapter{delay: 2 * time.Millisecond}
+	adapter.finalized.Store(5)
+	adapter.latest.Store(8)
+
+	storeConfig := store.NewMemoryConfig()
+	DB := storeConfig.MustOpenOrCreate()
+
+	processor := newTestDBProcessor()
+
+	syncer, err := NewSyncer(SyncerParams[testutil.Data]{
+		Adapter:         adapter,
+		DB:              DB,
+		BatchProcessors: []db.BatchProcessor[testutil.Data]{processor},
+		Processors:      []db.RevertableProcessor[testutil.Data]{processor},
+	}, SyncerOption{
+		Poller:               poll.Option{IdleInterval: 10 * time.Millisecond},
+		Latest:               true,
+		CatchUpThreshold:     10,
+		CatchUpCheckInterval: 20 * time.Millisecond,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, PhaseIdle, syncer.Phase())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go syncer.Sync(ctx, &wg)
+
+	// catch up to block 5, and then sync the latest blocks
+	processor.waitFor(t, testutil.Data{Number: 8, Hash: "DataHash-8", ParentHash: "DataHash-7"})
+	assert.Equal(t, PhaseLatest, syncer.Phase())
+
+	// fell behind too much, and switch back to catch up phase
+	adapter.finalized.Store(100)
+	adapter.latest.Store(100)
+
+	processor.waitFor(t, testutil.Data{Number: 100, Hash: "DataHash-100", ParentHash: "DataHash-99"})
+	assert.Equal(t, PhaseLatest, syncer.Phase())
+
+	cancel()
+	wg.Wait()
+
+	assert.Equal(t, PhaseIdle, syncer.Phase())
+
+	assert.Equal(t, 2, len(processor.batches))
+	assert.Equal(t, []uint64{0, 1, 2, 3, 4, 5}, processor.toNumberSlice(processor.batches[0]))
+	assert.Equal(t, uint64(100), processor.batches[1][len(processor.batches[1])-1].Number)
+	assert.Nil(t, processor.drops)
+}
+
+func TestSyncerControl(t *testing.T) {
+	adapter := &syncerTestAdapter{}
+	adapter.finalized.Store(5)
+	adapter.latest.Store(5)
+
+	storeConfig := store.NewMemoryConfig()
+	DB := storeConfig.MustOpenOrCreate()
+
+	processor := newTestDBProcessor()
+
+	syncer, err := NewSyncer(SyncerParams[testutil.Data]{
+		Adapter:         adapter,
+		DB:              DB,
+		BatchProcessors: []db.BatchProcessor[testutil.Data]{processor},
+		Processors:      []db.RevertableProcessor[testutil.Data]{processor},
+	}, SyncerOption{
+		Poller: poll.Option{IdleInterval: 10 * time.Millisecond},
+	})
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go syncer.Sync(ctx, &wg)
+
+	// catch up to block 5, and then sync the finalized blocks
+	processor.waitFor(t, testutil.Data{Number: 5, Hash: "DataHash-5", ParentHash: "DataHash-4"})
+	adapter.finalized.Store(8)
+	processor.waitFor(t, testutil.Data{Number: 8, Hash: "DataHash-8", ParentHash: "DataHash-7"})
+
+	assert.NoError(t, syncer.Pause(ctx))
+	assert.True(t, syncer.Status().Paused)
+	assert.True(t, syncer.Status().Healthy)
+
+	// rewind to block 7
+	assert.ErrorIs(t, syncer.Rewind(ctx, 10), ErrBlockNotProcessed)
+	assert.NoError(t, syncer.Rewind(ctx, 7))
+	assert.Equal(t, uint64(7), syncer.Status().NextBlockNumber)
+	assert.Equal(t, PhasePaused, syncer.Phase())
+
+	// reindex not supported
+	assert.Error(t, syncer.Reindex(ctx, 1, 2))
+
+	// catch up again once resumed
+	syncer.Resume()
+	processor.waitFor(t, testutil.Data{Number: 8, Hash: "DataHash-8", ParentHash: "DataHash-7"})
+
+	cancel()
+	wg.Wait()
+
+	processor.assertData(t, [][]uint64{{0, 1, 2, 3, 4, 5}, {7, 8}}, []uint64{6}, [][]uint64{{7, 8}})
+}
+
+func TestNewSyncerProcessors(t *testing.T) {
+	storeConfig := store.NewMemoryConfig()
+	DB := storeConfig.MustOpenOrCreate()
+
+	// batch processor should be one of the revertable processors
+	_, err := NewSyncer(SyncerParams[testutil.Data]{
+		Adapter:         &syncerTestAdapter{},
+		DB:              DB,
+		BatchProcessors: []db.BatchProcessor[testutil.Data]{newTestDBProcessor()},
+		Processors:      []db.RevertableProcessor[testutil.Data]{newTestDBProcessor()},
+	})
+	assert.ErrorContains(t, err, "not found in processors")
+
+	processor := newTestDBProcessor()
+
+	_, err = NewSyncer(SyncerParams[testutil.Data]{
+		Adapter:         &syncerTestAdapter{},
+		DB:              DB,
+		BatchProcessors: []db.BatchProcessor[testutil.Data]{processor},
+		Processors:      []db.RevertableProcessor[testutil.Data]{newTestDBProcessor(), processor},
+	})
+	assert.NoError(t, err)
+}
@@ -78,6 +78,22 @@ func (ch *MemoryBoundedChannel[T]) RecvCh() <-chan T {
 	return ch.recvCh
 }
 
+// Len returns the number of buffered items in channel, which is thread-safe.
+func (ch *MemoryBoundedChannel[T]) Len() int {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+
+	return ch.buffer.Len()
+}
+
+// Bytes returns the total memory size of buffered items in channel, which is thread-safe.
+func (ch *MemoryBoundedChannel[T]) Bytes() int {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+
+	return ch.curBytes
+}
+
 // Close releases resources associated with the channel.
 //
 // Note, it will panic if continue to write data after closed, which is the same as the built-in channel behavior.
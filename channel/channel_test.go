@@ -35,6 +35,9 @@ func testMemoryBoundedChannelCapacity(t *testing.T, capacity int) {
 	// cannot write data again, since channel is waiting for notFullCond
 	mustWriteChannelBlocked(channel.SendCh(), SizedData{capacity, 10})
 
+	assert.Equal(t, capacity, channel.Len())
+	assert.Equal(t, 10*capacity, channel.Bytes())
+
 	// read data
 	for i := 0; i < capacity; i++ {
 		assertReadChannel(t, SizedData{i, 10}, channel.RecvCh())
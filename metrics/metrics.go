@@ -101,6 +101,18 @@ func GetOrRegisterTimer(nameFormat string, nameArgs ...interface{}) metrics.Time
 	return metrics.GetOrRegisterTimer(name, nil)
 }
 
+// RegisterFunctionalGauge registers a functional gauge into default registry by a specified metrics name,
+// which computes the value on demand. Note, the existing metric of the same name will be replaced.
+func RegisterFunctionalGauge(f func() int64, nameFormat string, nameArgs ...interface{}) metrics.Gauge {
+	name := fmt.Sprintf(nameFormat, nameArgs...)
+	gauge := metrics.NewFunctionalGauge(f)
+
+	metrics.DefaultRegistry.Unregister(name)
+	metrics.DefaultRegistry.Register(name, gauge)
+
+	return gauge
+}
+
 func getOrRegister[T any](factory func() T, name string, args ...interface{}) T {
 	name = fmt.Sprintf(name, args...)
 	return metrics.DefaultRegistry.GetOrRegister(name, factory).(T)
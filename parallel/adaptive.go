@@ -0,0 +1,147 @@
+package parallel
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/mcuadros/go-defaults"
+	"github.com/pkg/errors"
+)
+
+type AdaptiveOption struct {
+	// Enabled indicates to tune the number of active routines at runtime.
+	Enabled bool
+
+	// MinRoutines is the minimum number of active routines.
+	MinRoutines int `default:"1"`
+
+	// LatencyThreshold is the max latency of a task, otherwise, it will be regarded as overloaded.
+	LatencyThreshold time.Duration `default:"3s"`
+
+	// DecreaseFactor is the multiplicative factor to decrease the number of active routines when overloaded.
+	DecreaseFactor float64 `default:"0.5"`
+
+	// DecreaseInterval is the min interval between two decreases, so as to avoid decreasing too fast due to
+	// the in-flight tasks that started before the last decrease.
+	DecreaseInterval time.Duration `default:"1s"`
+}
+
+// AdaptiveLimiter limits the number of active routines, and tunes the limit at runtime from observed latency
+// and error rate in AIMD (additive increase/multiplicative decrease) manner:
+//
+//   - Increases the limit by 1 once the number of succeeded tasks reached the current limit.
+//   - Decreases the limit by DecreaseFactor once any task failed or timed out.
+type AdaptiveLimiter struct {
+	option   AdaptiveOption
+	maxLimit int
+
+	mu          sync.Mutex
+	limit       int
+	active      int
+	succeeded   int             // number of succeeded tasks since the last increase
+	decreasedAt time.Time       // time of the last decrease
+	released    chan struct{}   // notify the waiters once any routine released
+	onChange    func(limit int) // optional callback once limit changed
+}
+
+// NewAdaptiveLimiter creates a new adaptive limiter with the given max number of active routines, which
+// starts with the max limit.
+func NewAdaptiveLimiter(maxLimit int, option AdaptiveOption, onChange ...func(limit int)) *AdaptiveLimiter {
+	defaults.SetDefaults(&option)
+
+	maxLimit = max(maxLimit, 1)
+	option.MinRoutines = min(max(option.MinRoutines, 1), maxLimit)
+
+	limiter := AdaptiveLimiter{
+		option:   option,
+		maxLimit: maxLimit,
+		limit:    maxLimit,
+		released: make(chan struct{}),
+	}
+
+	if len(onChange) > 0 {
+		limiter.onChange = onChange[0]
+	}
+
+	return &limiter
+}
+
+// Limit returns the current limit of active routines.
+func (limiter *AdaptiveLimiter) Limit() int {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	return limiter.limit
+}
+
+// Acquire blocks until the number of active routines is less than the current limit, or context done.
+func (limiter *AdaptiveLimiter) Acquire(ctx context.Context) error {
+	for {
+		limiter.mu.Lock()
+
+		if limiter.active < limiter.limit {
+			limiter.active++
+			limiter.mu.Unlock()
+			return nil
+		}
+
+		released := limiter.released
+		limiter.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-released:
+		}
+	}
+}
+
+// Release releases an active routine, and tunes the limit with the task latency and error.
+//
+// Note, the limit will not be tuned if task cancelled, e.g. service shutdown.
+func (limiter *AdaptiveLimiter) Release(latency time.Duration, err error) {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	limiter.active--
+
+	oldLimit := limiter.limit
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		// not overloaded
+	case err != nil || latency > limiter.option.LatencyThreshold:
+		limiter.decrease()
+	default:
+		limiter.increase()
+	}
+
+	// notify all waiters
+	close(limiter.released)
+	limiter.released = make(chan struct{})
+
+	if limiter.onChange != nil && limiter.limit != oldLimit {
+		limiter.onChange(limiter.limit)
+	}
+}
+
+func (limiter *AdaptiveLimiter) increase() {
+	limiter.succeeded++
+
+	if limiter.succeeded >= limiter.limit && limiter.limit < limiter.maxLimit {
+		limiter.limit++
+		limiter.succeeded = 0
+	}
+}
+
+func (limiter *AdaptiveLimiter) decrease() {
+	limiter.succeeded = 0
+
+	if time.Since(limiter.decreasedAt) < limiter.option.DecreaseInterval {
+		return
+	}
+
+	limiter.limit = max(int(float64(limiter.limit)*limiter.option.DecreaseFactor), limiter.option.MinRoutines)
+	limiter.decreasedAt = time.Now()
+}
@@ -0,0 +1,72 @@
+package parallel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdaptiveLimiter(t *testing.T) {
+	limiter := NewAdaptiveLimiter(8, AdaptiveOption{
+		MinRoutines:      2,
+		LatencyThreshold: time.Second,
+		DecreaseInterval: time.Hour,
+	})
+	assert.Equal(t, 8, limiter.Limit())
+
+	// multiplicative decrease on error
+	assert.NoError(t, limiter.Acquire(context.Background()))
+	limiter.Release(time.Millisecond, errors.New("rate limited"))
+	assert.Equal(t, 4, limiter.Limit())
+
+	// decrease at most once during interval
+	assert.NoError(t, limiter.Acquire(context.Background()))
+	limiter.Release(2*time.Second, nil)
+	assert.Equal(t, 4, limiter.Limit())
+
+	// additive increase once succeeded tasks reached the limit
+	for i := 0; i < 4; i++ {
+		assert.NoError(t, limiter.Acquire(context.Background()))
+		limiter.Release(time.Millisecond, nil)
+	}
+	assert.Equal(t, 5, limiter.Limit())
+
+	// not tuned if cancelled
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, limiter.Acquire(context.Background()))
+
+		if i == 2 {
+			limiter.Release(time.Millisecond, fmt.Errorf("request aborted: %w", context.Canceled))
+		} else {
+			limiter.Release(time.Millisecond, nil)
+		}
+	}
+	assert.Equal(t, 5, limiter.Limit())
+
+	assert.NoError(t, limiter.Acquire(context.Background()))
+	limiter.Release(time.Millisecond, nil)
+	assert.Equal(t, 6, limiter.Limit())
+}
+
+func TestAdaptiveLimiterAcquire(t *testing.T) {
+	limiter := NewAdaptiveLimiter(2, AdaptiveOption{})
+
+	assert.NoError(t, limiter.Acquire(context.Background()))
+	assert.NoError(t, limiter.Acquire(context.Background()))
+
+	// blocked until context done
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Error(t, limiter.Acquire(ctx))
+
+	// unblocked once any routine released
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		limiter.Release(time.Millisecond, nil)
+	}()
+	assert.NoError(t, limiter.Acquire(context.Background()))
+}